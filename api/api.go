@@ -0,0 +1,274 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package api provides functions to run whole ictools operations
+// (import, transalign) in-process. Unlike the command line tool,
+// the functions read and write data via io.Reader and io.Writer,
+// they can be cancelled via context.Context and they return errors
+// instead of terminating the program.
+package api
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/czcorpus/ictools/calign"
+	"github.com/czcorpus/ictools/fixgaps"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/czcorpus/ictools/pipeline"
+	"github.com/czcorpus/ictools/transalign"
+)
+
+// OverlapError is returned by Import in case some items of the source
+// alignment overlap already covered ranges. The output is written anyway
+// (with ERROR marks instead of such items) but it cannot be used to produce
+// a correct alignment.
+type OverlapError struct {
+	Errors []*fixgaps.FixGapsError
+}
+
+func (e *OverlapError) Error() string {
+	return fmt.Sprintf("Finished with %d errors. The result cannot be used to produce a correct alignment.", len(e.Errors))
+}
+
+// ImportOptions configures Import
+type ImportOptions struct {
+
+	// InputFormat is one of calign.InputFormat* values
+	// (calign.InputFormatXCES if empty)
+	InputFormat string
+
+	// Attr1 and Attr2 map structure IDs of LANG and PIVOT
+	// to structure positions
+	Attr1 calign.AttribMapper
+	Attr2 calign.AttribMapper
+
+	// IDs1 and IDs2 contain ordered structure IDs of LANG and PIVOT
+	// sentences (used only by sentence aligner input formats; if nil,
+	// sentence index = structure position)
+	IDs1 []string
+	IDs2 []string
+
+	// Header describes the output data. Structure sizes are required
+	// to fill in unaligned structures at the end of both corpora.
+	Header mapping.Header
+
+	// OutputFormat is one of mapping.Format* values (mapping.FormatText if empty)
+	OutputFormat string
+
+	// BufferSize is a max. line length (bufio.MaxScanTokenSize if 0)
+	BufferSize int
+
+	// SourceName identifies the source data in log messages
+	SourceName string
+
+	// OnOverlap is called for each source item overlapping an already
+	// covered range (optional; see also OverlapError)
+	OnOverlap func(err *fixgaps.FixGapsError)
+}
+
+// Import transforms an alignment of a supported input format read from
+// src into a numeric LANG -> PIVOT mapping written to dst (i.e. it runs
+// calign, fixgaps and compress). In case of an error (including cancellation),
+// the output is incomplete and its checksum is not written. The only exception
+// is *OverlapError returned along with a complete output.
+func Import(ctx context.Context, src io.Reader, dst io.Writer, opts ImportOptions) error {
+	inputFormat := opts.InputFormat
+	if inputFormat == "" {
+		inputFormat = calign.InputFormatXCES
+	}
+	outputFormat := opts.OutputFormat
+	if outputFormat == "" {
+		outputFormat = mapping.FormatText
+	}
+	bufferSize := opts.BufferSize
+	if bufferSize == 0 {
+		bufferSize = bufio.MaxScanTokenSize
+	}
+	processor, err := calign.NewInputReader(inputFormat, opts.Attr1, opts.Attr2, opts.IDs1, opts.IDs2)
+	if err != nil {
+		return err
+	}
+	writer, err := mapping.NewWriter(dst, outputFormat, opts.Header)
+	if err != nil {
+		return err
+	}
+	overlaps := make([]*fixgaps.FixGapsError, 0, 10)
+	err = pipeline.New(pipeline.Parse(processor, src, opts.SourceName, bufferSize)).
+		Then(pipeline.FixGaps(true, opts.Header.Size1, opts.Header.Size2, func(err *fixgaps.FixGapsError) {
+			if opts.OnOverlap != nil {
+				opts.OnOverlap(err)
+			}
+			overlaps = append(overlaps, err)
+		})).
+		Then(pipeline.Compress(true)).
+		Run(ctx, pipeline.Write(writer))
+	if err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	if len(overlaps) > 0 {
+		return &OverlapError{Errors: overlaps}
+	}
+	return nil
+}
+
+// ----------------------------------------------
+
+// TransalignOptions configures Transalign
+type TransalignOptions struct {
+
+	// PivotLeft1 and PivotLeft2 specify that the respective source
+	// is a PIVOT -> LANG mapping (see transalign.DetectPivotSides)
+	PivotLeft1 bool
+	PivotLeft2 bool
+
+	// Streaming specifies that both the sources are read sequentially
+	// with bounded memory (see transalign.RunStreaming)
+	Streaming bool
+
+	// OutputFormat is one of mapping.Format* values (mapping.FormatText if empty)
+	OutputFormat string
+
+	// IgnoreHeader disables verification that both the sources have
+	// been created for the same pivot (see transalign.VerifyHeaders)
+	IgnoreHeader bool
+
+	// Version is an ictools version written to the output header
+	Version string
+
+	// SourceName1 and SourceName2 identify the sources in log messages
+	SourceName1 string
+	SourceName2 string
+}
+
+func createTransalignHeader(h1, h2 mapping.Header, opts TransalignOptions) (mapping.Header, error) {
+	if !opts.IgnoreHeader {
+		if err := transalign.VerifyHeaders(h1, h2); err != nil {
+			return mapping.Header{}, err
+		}
+	}
+	ans := transalign.CreateHeader(h1, h2)
+	ans.Version = opts.Version
+	return ans, nil
+}
+
+// Transalign generates a LANG1 -> LANG2 mapping from LANG1 -> PIVOT (src1)
+// and LANG2 -> PIVOT (src2) numeric mappings and writes it to dst. In case
+// the headers of the sources do not match, *mapping.HeaderMismatchError
+// is returned. In case of an error (including cancellation), the output
+// is incomplete and its checksum is not written.
+func Transalign(ctx context.Context, src1, src2 io.Reader, dst io.Writer, opts TransalignOptions) error {
+	src1 = pipeline.ContextReader(ctx, src1)
+	src2 = pipeline.ContextReader(ctx, src2)
+	if opts.Streaming {
+		ps1, err := transalign.NewPivotStream(src1)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+		ps1.SetPivotLeft(opts.PivotLeft1)
+		ps2, err := transalign.NewPivotStream(src2)
+		if err != nil {
+			return contextErr(ctx, err)
+		}
+		ps2.SetPivotLeft(opts.PivotLeft2)
+		header, err := createTransalignHeader(ps1.Header(), ps2.Header(), opts)
+		if err != nil {
+			return err
+		}
+		return writeTransalignResult(ctx, dst, opts.OutputFormat, header, pipeline.TransalignStreaming(ps1, ps2))
+	}
+	pm1 := transalign.NewPivotMappingFromReader(src1, opts.SourceName1)
+	pm1.SetPivotLeft(opts.PivotLeft1)
+	if err := pm1.Load(); err != nil {
+		return contextErr(ctx, err)
+	}
+	pm2 := transalign.NewPivotMappingFromReader(src2, opts.SourceName2)
+	pm2.SetPivotLeft(opts.PivotLeft2)
+	if err := pm2.Load(); err != nil {
+		return contextErr(ctx, err)
+	}
+	return TransalignMappings(ctx, pm1, pm2, dst, opts)
+}
+
+// TransalignMappings works like Transalign but with already loaded
+// pivot mappings (which are only read so they can be shared by
+// concurrent calls). Options related to reading the sources are ignored.
+func TransalignMappings(ctx context.Context, pm1, pm2 *transalign.PivotMapping, dst io.Writer, opts TransalignOptions) error {
+	header, err := createTransalignHeader(pm1.Header(), pm2.Header(), opts)
+	if err != nil {
+		return err
+	}
+	return writeTransalignResult(ctx, dst, opts.OutputFormat, header, pipeline.Transalign(pm1, pm2))
+}
+
+// WriteTransalignResult compresses items produced by a transalign run
+// function (e.g. transalign.Run) and writes them to dst. Gap flags
+// are not written as they have no meaning in a LANG1 -> LANG2 mapping.
+// In case of an error, the output is incomplete and its checksum is not written.
+func WriteTransalignResult(
+	ctx context.Context,
+	dst io.Writer,
+	outputFormat string,
+	header mapping.Header,
+	run func(onItem func(mapping.Mapping)) error,
+) error {
+	return writeTransalignResult(ctx, dst, outputFormat, header, pipeline.Func(run))
+}
+
+func writeTransalignResult(
+	ctx context.Context,
+	dst io.Writer,
+	outputFormat string,
+	header mapping.Header,
+	source pipeline.Source,
+) error {
+	if outputFormat == "" {
+		outputFormat = mapping.FormatText
+	}
+	writer, err := mapping.NewWriter(dst, outputFormat, header)
+	if err != nil {
+		return err
+	}
+	err = pipeline.New(source).
+		Then(pipeline.Filter(func(item mapping.Mapping) bool {
+			return !item.IsEmpty()
+		})).
+		Then(pipeline.Compress(false)).
+		Then(pipeline.Transform(func(item mapping.Mapping) mapping.Mapping {
+			item.IsGap = false
+			return item
+		})).
+		Run(ctx, pipeline.Write(writer))
+	if err != nil {
+		return err
+	}
+	return writer.Close()
+}
+
+// contextErr returns the context error in case the context
+// has been cancelled (which is the actual cause of err in
+// such case), err otherwise
+func contextErr(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/czcorpus/ictools/calign"
+	"github.com/czcorpus/ictools/fixgaps"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockAttr maps IDs like "foo:N" to N (for N < size)
+func createImportOptions() ImportOptions {
+	return ImportOptions{
+		Attr1:        &attribtest.PrefixAttr{Prefix: "foo", Size: 4},
+		Attr2:        &attribtest.PrefixAttr{Prefix: "bar", Size: 4},
+		Header:       mapping.Header{Corpus1: "foo", Corpus2: "bar", Attr: "s.id", Size1: 4, Size2: 4},
+		OutputFormat: mapping.FormatPlain,
+		SourceName:   "test",
+	}
+}
+
+func TestImport(t *testing.T) {
+	src := strings.NewReader(
+		"<linkGrp>\n" +
+			"<link type=\"1-1\" xtargets=\"foo:0;bar:0\" />\n" +
+			"<link type=\"1-2\" xtargets=\"foo:1;bar:1 bar:2\" />\n" +
+			"</linkGrp>\n")
+	var dst bytes.Buffer
+	err := Import(context.Background(), src, &dst, createImportOptions())
+	assert.Nil(t, err)
+	assert.Equal(t, "0\t0\n1\t1,2\n2,3\t-1\tg\n-1\t3\tg\n", dst.String())
+}
+
+func TestImportUnknownFormat(t *testing.T) {
+	opts := createImportOptions()
+	opts.InputFormat = "foo"
+	err := Import(context.Background(), strings.NewReader(""), &bytes.Buffer{}, opts)
+	assert.IsType(t, calign.UnknownFormatError{}, err)
+}
+
+func TestImportCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	src := strings.NewReader("<link type=\"1-1\" xtargets=\"foo:0;bar:0\" />\n")
+	opts := createImportOptions()
+	opts.OutputFormat = mapping.FormatText
+	var dst bytes.Buffer
+	err := Import(ctx, src, &dst, opts)
+	assert.Equal(t, context.Canceled, err)
+	assert.NotContains(t, dst.String(), "checksum")
+}
+
+func TestImportOverlap(t *testing.T) {
+	src := strings.NewReader(
+		"<link type=\"1-1\" xtargets=\"foo:0;bar:0\" />\n" +
+			"<link type=\"1-1\" xtargets=\"foo:2;bar:2\" />\n" +
+			"<link type=\"1-1\" xtargets=\"foo:1;bar:1\" />\n")
+	opts := createImportOptions()
+	numReported := 0
+	opts.OnOverlap = func(err *fixgaps.FixGapsError) {
+		numReported++
+	}
+	var dst bytes.Buffer
+	err := Import(context.Background(), src, &dst, opts)
+	assert.IsType(t, &OverlapError{}, err)
+	assert.Equal(t, 1, len(err.(*OverlapError).Errors))
+	assert.Equal(t, 1, numReported)
+	assert.Contains(t, dst.String(), mapping.ErrorMark)
+}
+
+const (
+	transalignData1 = "#@ corpus1=foo\n#@ corpus2=pv\n#@ attr=s.id\n#@ size1=3\n#@ size2=3\n" +
+		"0\t0\n1\t-1\n2\t1,2\n#@ checksum=crc32:34086410\n"
+	transalignData2 = "#@ corpus1=bar\n#@ corpus2=pv\n#@ attr=s.id\n#@ size1=3\n#@ size2=3\n" +
+		"0\t0\n1\t1\n2\t2\n#@ checksum=crc32:7c1efae0\n"
+)
+
+func runTransalign(ctx context.Context, data1, data2 string, opts TransalignOptions) (string, error) {
+	var dst bytes.Buffer
+	err := Transalign(ctx, strings.NewReader(data1), strings.NewReader(data2), &dst, opts)
+	return dst.String(), err
+}
+
+func TestTransalign(t *testing.T) {
+	ans, err := runTransalign(context.Background(), transalignData1, transalignData2,
+		TransalignOptions{OutputFormat: mapping.FormatPlain})
+	assert.Nil(t, err)
+	assert.Equal(t, "0\t0\n1\t-1\n2\t1,2\n", ans)
+}
+
+func TestTransalignStreamingSameAsInMemory(t *testing.T) {
+	ans1, err := runTransalign(context.Background(), transalignData1, transalignData2,
+		TransalignOptions{Version: "1.0"})
+	assert.Nil(t, err)
+	ans2, err := runTransalign(context.Background(), transalignData1, transalignData2,
+		TransalignOptions{Version: "1.0", Streaming: true})
+	assert.Nil(t, err)
+	assert.Equal(t, ans1, ans2)
+	assert.Contains(t, ans1, "#@ corpus1=foo\n#@ corpus2=bar\n")
+	assert.Contains(t, ans1, "#@ ictools=1.0\n")
+}
+
+func TestTransalignHeaderMismatch(t *testing.T) {
+	data2 := strings.Replace(transalignData2, "size2=3", "size2=4", 1)
+	_, err := runTransalign(context.Background(), transalignData1, data2, TransalignOptions{})
+	assert.IsType(t, &mapping.HeaderMismatchError{}, err)
+	_, err = runTransalign(context.Background(), transalignData1, data2, TransalignOptions{IgnoreHeader: true})
+	assert.Nil(t, err)
+}
+
+func TestTransalignCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, streaming := range []bool{false, true} {
+		ans, err := runTransalign(ctx, transalignData1, transalignData2, TransalignOptions{Streaming: streaming})
+		assert.Equal(t, context.Canceled, err)
+		assert.NotContains(t, ans, "checksum")
+	}
+}
@@ -15,11 +15,14 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
+//go:build !nomanatee
+
 #include "corp/corpus.hh"
 #include "attrib.h"
 #include <string.h>
 #include <stdio.h>
 #include <iostream>
+#include <stdexcept>
 
 using namespace std;
 
@@ -52,6 +55,32 @@ const char* attr_id2str(PosAttrV attr, long ident) {
     return ((PosAttr *)attr)->id2str(ident);
 }
 
+const char* attr_pos2str(PosAttrV attr, long pos) {
+    return ((PosAttr *)attr)->pos2str(pos);
+}
+
+SpanRetval get_struct_span(CorpusV corpus, const char* structName, long idx) {
+    string tmp(structName);
+    SpanRetval ans {
+        0,
+        0,
+        nullptr
+    };
+    try {
+        Structure *strct = ((Corpus*)corpus)->get_struct(tmp);
+        if (idx < 0 || idx >= strct->rng->size()) {
+            throw std::out_of_range("structure index out of range");
+        }
+        ans.beg = strct->rng->beg_at(idx);
+        ans.end = strct->rng->end_at(idx);
+        return ans;
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+        return ans;
+    }
+}
+
 StructSizeRetval get_struct_size(CorpusV corpus, const char* structName) {
     string tmp(structName);
     StructSizeRetval ans {
@@ -69,6 +98,37 @@ StructSizeRetval get_struct_size(CorpusV corpus, const char* structName) {
     }
 }
 
+StrRetval get_struct_text(CorpusV corpus, const char* structName, const char* attrName, long first, long last) {
+    StrRetval ans {
+        nullptr,
+        nullptr
+    };
+    try {
+        Structure *strct = ((Corpus*)corpus)->get_struct(string(structName));
+        PosAttr *attr = ((Corpus*)corpus)->get_attr(string(attrName));
+        Position beg = strct->rng->beg_at(first);
+        Position end = strct->rng->end_at(last);
+        if (beg < 0 || end < beg) {
+            throw std::out_of_range("invalid structure range");
+        }
+        TextIterator *it = attr->textat(beg);
+        string ret;
+        for (Position pos = beg; pos < end; pos++) {
+            if (pos > beg) {
+                ret += ' ';
+            }
+            ret += it->next();
+        }
+        delete it;
+        ans.value = strdup(ret.c_str());
+        return ans;
+
+    } catch (std::exception &e) {
+        ans.err = strdup(e.what());
+        return ans;
+    }
+}
+
 CorpusRetval open_corpus(const char* corpusPath) {
     string tmp(corpusPath);
     CorpusRetval ans {
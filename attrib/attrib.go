@@ -15,6 +15,8 @@
 // You should have received a copy of the GNU General Public License
 // along with this program.  If not, see <http://www.gnu.org/licenses/>.
 
+//go:build !nomanatee
+
 // Package attrib contains wrapper functions and types
 // used to access Manatee C library.
 package attrib
@@ -31,6 +33,12 @@ import (
 	"unsafe"
 )
 
+const (
+	// DefaultBackend is the backend used when no explicit
+	// choice is made. With Manatee compiled in we prefer it.
+	DefaultBackend = BackendManatee
+)
+
 // GoCorpus is a wrapper for Manatee Corpus instance
 type GoCorpus struct {
 	corp C.CorpusV
@@ -48,6 +56,69 @@ func GetStructSize(corpus GoCorpus, name string) (int, error) {
 	return int(ans.value), nil
 }
 
+// StructSize returns a number of occurences of a specific
+// structure (see GetStructSize).
+func (gc GoCorpus) StructSize(name string) (int, error) {
+	return GetStructSize(gc, name)
+}
+
+// Attr returns a Manatee attribute of the corpus (see OpenAttr).
+func (gc GoCorpus) Attr(name string) (PosAttr, error) {
+	attr, err := OpenAttr(gc, name)
+	if err != nil {
+		return nil, err
+	}
+	return attr, nil
+}
+
+// StructSpan returns token positions covered by the idx-th
+// occurrence of a structure.
+func (gc GoCorpus) StructSpan(structName string, idx int) (Span, error) {
+	cStructName := C.CString(structName)
+	defer C.free(unsafe.Pointer(cStructName))
+	ans := C.get_struct_span(gc.corp, cStructName, C.long(idx))
+	if ans.err != nil {
+		err := fmt.Errorf(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return Span{}, err
+	}
+	return Span{Beg: int(ans.beg), End: int(ans.end)}, nil
+}
+
+// Tokens returns values of a positional attribute at positions
+// of a provided span.
+func (gc GoCorpus) Tokens(attrName string, span Span) ([]string, error) {
+	if span.End < span.Beg || span.Beg < 0 {
+		return nil, fmt.Errorf("invalid position range [%d, %d)", span.Beg, span.End)
+	}
+	attr, err := OpenAttr(gc, attrName)
+	if err != nil {
+		return nil, err
+	}
+	ans := make([]string, span.Len())
+	for i := range ans {
+		ans[i] = attr.Pos2Str(span.Beg + i)
+	}
+	return ans, nil
+}
+
+// StructText returns values of a positional attribute covered by
+// structures first...last (both inclusive) separated by spaces.
+func (gc GoCorpus) StructText(structName string, attrName string, first int, last int) (string, error) {
+	cStructName := C.CString(structName)
+	defer C.free(unsafe.Pointer(cStructName))
+	cAttrName := C.CString(attrName)
+	defer C.free(unsafe.Pointer(cAttrName))
+	ans := C.get_struct_text(gc.corp, cStructName, cAttrName, C.long(first), C.long(last))
+	if ans.err != nil {
+		err := fmt.Errorf(C.GoString(ans.err))
+		defer C.free(unsafe.Pointer(ans.err))
+		return "", err
+	}
+	defer C.free(unsafe.Pointer(ans.value))
+	return C.GoString(ans.value), nil
+}
+
 // GoPosAttr is a wrapper for Manatee PosAttr
 // (note: structural attributes belong here too)
 type GoPosAttr struct {
@@ -66,6 +137,12 @@ func (gpa GoPosAttr) ID2Str(value int) string {
 	return C.GoString(C.attr_id2str(gpa.attr, C.long(value)))
 }
 
+// Pos2Str returns a value of a positional attribute
+// at a specified corpus position.
+func (gpa GoPosAttr) Pos2Str(pos int) string {
+	return C.GoString(C.attr_pos2str(gpa.attr, C.long(pos)))
+}
+
 // OpenCorpus is a factory function creating
 // a Manatee corpus wrapper.
 func OpenCorpus(path string) (GoCorpus, error) {
@@ -95,3 +172,11 @@ func OpenAttr(corpus GoCorpus, name string) (GoPosAttr, error) {
 	ret.attr = ans.value
 	return ret, nil
 }
+
+func openManateeCorpus(path string) (Corpus, error) {
+	corp, err := OpenCorpus(path)
+	if err != nil {
+		return nil, err
+	}
+	return corp, nil
+}
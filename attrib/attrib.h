@@ -54,6 +54,27 @@ typedef struct StructSizeRetval {
     const char * err;
 } StructSizeRetval;
 
+/**
+ * StrRetval wraps both
+ * a returned (dynamically allocated) string
+ * and possible error
+ */
+typedef struct StrRetval {
+    const char * value;
+    const char * err;
+} StrRetval;
+
+/**
+ * SpanRetval wraps both
+ * a returned token span [beg, end)
+ * and possible error
+ */
+typedef struct SpanRetval {
+    long beg;
+    long end;
+    const char * err;
+} SpanRetval;
+
 /**
  * Provide number of structures of a given name
  */
@@ -74,6 +95,23 @@ long attr_str2id(PosAttrV attr, const char* str);
  */
 const char* attr_id2str(PosAttrV attr, long ident);
 
+/**
+ * Get a value of a positional attribute at a provided position.
+ */
+const char* attr_pos2str(PosAttrV attr, long pos);
+
+/**
+ * Get token positions [beg, end) covered by the idx-th
+ * structure of a given name.
+ */
+SpanRetval get_struct_span(CorpusV corpus, const char* structName, long idx);
+
+/**
+ * Get values of a positional attribute covered by structures
+ * first...last (both inclusive) separated by spaces.
+ */
+StrRetval get_struct_text(CorpusV corpus, const char* structName, const char* attrName, long first, long last);
+
 /**
  * Create a Manatee corpus instance
  */
@@ -0,0 +1,76 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package attribtest provides fake structural attributes
+// for testing packages working with attrib.PosAttr.
+package attribtest
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ListAttr is an attribute with explicitly listed
+// string IDs (the i-th ID belongs to the i-th structure).
+type ListAttr struct {
+	IDs []string
+}
+
+// Str2ID returns a position of a structure with a specified ID
+// or -1 if there is no such structure.
+func (la *ListAttr) Str2ID(value string) int {
+	for i, v := range la.IDs {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// ID2Str returns an ID of a structure at a specified position.
+// For positions outside the list, an empty string is returned.
+func (la *ListAttr) ID2Str(ident int) string {
+	if ident < 0 || ident >= len(la.IDs) {
+		return ""
+	}
+	return la.IDs[ident]
+}
+
+// PrefixAttr is an attribute with IDs in the form [Prefix]:[position]
+// (e.g. "cs:s:3"). In case Size is positive, Str2ID accepts only
+// positions lower than Size.
+type PrefixAttr struct {
+	Prefix string
+	Size   int
+}
+
+// Str2ID parses a position from a prefixed ID. For IDs with a different
+// prefix and for positions out of range, -1 is returned.
+func (pa *PrefixAttr) Str2ID(value string) int {
+	if !strings.HasPrefix(value, pa.Prefix+":") {
+		return -1
+	}
+	ans, err := strconv.Atoi(value[len(pa.Prefix)+1:])
+	if err != nil || ans < 0 || pa.Size > 0 && ans >= pa.Size {
+		return -1
+	}
+	return ans
+}
+
+// ID2Str creates a prefixed ID of a structure at a specified position
+func (pa *PrefixAttr) ID2Str(ident int) string {
+	return pa.Prefix + ":" + strconv.Itoa(ident)
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package attrib
+
+import (
+	"fmt"
+)
+
+const (
+	// BackendManatee accesses corpus data via the Manatee C++ library
+	BackendManatee = "manatee"
+
+	// BackendNative reads compiled corpus data files directly
+	// without any need for Manatee to be installed.
+	BackendNative = "native"
+)
+
+// Corpus is a backend independent representation of a corpus
+// providing just the information ictools need.
+type Corpus interface {
+
+	// StructSize returns a number of occurences of a specific structure
+	StructSize(name string) (int, error)
+
+	// Attr returns a positional or structural attribute
+	// (e.g. "s.id") of the corpus
+	Attr(name string) (PosAttr, error)
+
+	// StructSpan returns token positions covered by the idx-th
+	// occurrence of a structure
+	StructSpan(structName string, idx int) (Span, error)
+
+	// Tokens returns values of a positional attribute (e.g. "word",
+	// "lemma") at positions of a provided span
+	Tokens(attrName string, span Span) ([]string, error)
+
+	// StructText returns values of a positional attribute (typically
+	// "word") covered by structures first...last (both inclusive)
+	// separated by spaces.
+	StructText(structName string, attrName string, first int, last int) (string, error)
+}
+
+// Span is a half-open interval [Beg, End) of token positions
+type Span struct {
+	Beg int
+	End int
+}
+
+// Len returns number of tokens within the span
+func (s Span) Len() int {
+	return s.End - s.Beg
+}
+
+// Join creates a span starting at the beginning of s
+// and ending at the end of s2.
+func (s Span) Join(s2 Span) Span {
+	return Span{Beg: s.Beg, End: s2.End}
+}
+
+// PosAttr is a backend independent representation of an attribute
+// lexicon (note: structural attributes belong here too).
+// It is compatible with calign.AttribMapper.
+type PosAttr interface {
+	Str2ID(value string) int
+	ID2Str(ident int) string
+}
+
+// Open opens a corpus specified by its registry path using
+// a specified backend (BackendManatee, BackendNative).
+func Open(backend string, registryPath string) (Corpus, error) {
+	switch backend {
+	case BackendManatee:
+		return openManateeCorpus(registryPath)
+	case BackendNative:
+		corp, err := OpenNativeCorpus(registryPath)
+		if err != nil {
+			return nil, err
+		}
+		return corp, nil
+	}
+	return nil, fmt.Errorf("unknown corpus backend '%s'", backend)
+}
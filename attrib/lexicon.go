@@ -0,0 +1,138 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package attrib
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// lexicon is a pure Go reader of compiled Manatee attribute
+// lexicon files:
+//
+// * [attr].lex - NUL-terminated attribute values,
+// * [attr].lex.idx - int32 offsets of the values in [attr].lex (indexed by ID),
+// * [attr].lex.srt - int32 IDs ordered by their (byte-compared) values.
+//
+// All the files are loaded to memory.
+type lexicon struct {
+	data []byte
+	idx  []uint32
+	srt  []uint32
+}
+
+func readInt32File(path string) ([]uint32, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data)%4 != 0 {
+		return nil, fmt.Errorf("invalid size of %s (not a multiple of 4)", path)
+	}
+	ans := make([]uint32, len(data)/4)
+	for i := range ans {
+		ans[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	return ans, nil
+}
+
+// loadLexicon loads lexicon files for an attribute specified
+// by a path prefix (e.g. /corpora/data/syn2015/s.id).
+func loadLexicon(pathPrefix string) (*lexicon, error) {
+	if _, err := os.Stat(pathPrefix + ".lex.ovf"); err == nil {
+		return nil, fmt.Errorf("lexicons with overflow files are not supported (%s)", pathPrefix)
+	}
+	var err error
+	ans := &lexicon{}
+	ans.data, err = ioutil.ReadFile(pathPrefix + ".lex")
+	if err != nil {
+		return nil, err
+	}
+	ans.idx, err = readInt32File(pathPrefix + ".lex.idx")
+	if err != nil {
+		return nil, err
+	}
+	for i, offset := range ans.idx {
+		if int(offset) >= len(ans.data) {
+			return nil, fmt.Errorf("corrupted lexicon index %s.lex.idx (item %d)", pathPrefix, i)
+		}
+	}
+	ans.srt, err = readInt32File(pathPrefix + ".lex.srt")
+	if os.IsNotExist(err) {
+		ans.srt = ans.createSortedIndex()
+
+	} else if err != nil {
+		return nil, err
+
+	} else if len(ans.srt) != len(ans.idx) {
+		return nil, fmt.Errorf("lexicon sort index %s.lex.srt does not match the lexicon", pathPrefix)
+	}
+	return ans, nil
+}
+
+// createSortedIndex is used in case a lexicon comes without
+// a precompiled [attr].lex.srt file.
+func (lx *lexicon) createSortedIndex() []uint32 {
+	ans := make([]uint32, len(lx.idx))
+	for i := range ans {
+		ans[i] = uint32(i)
+	}
+	sort.Slice(ans, func(i, j int) bool {
+		return bytes.Compare(lx.value(int(ans[i])), lx.value(int(ans[j]))) < 0
+	})
+	return ans
+}
+
+func (lx *lexicon) value(ident int) []byte {
+	offset := lx.idx[ident]
+	end := bytes.IndexByte(lx.data[offset:], 0)
+	if end == -1 {
+		return lx.data[offset:]
+	}
+	return lx.data[offset : int(offset)+end]
+}
+
+// Size returns number of unique values in the lexicon
+func (lx *lexicon) Size() int {
+	return len(lx.idx)
+}
+
+// Str2ID returns an ID of a provided value or -1
+// if the value is not found (just like Manatee does).
+func (lx *lexicon) Str2ID(value string) int {
+	srch := []byte(value)
+	i := sort.Search(len(lx.srt), func(i int) bool {
+		return bytes.Compare(lx.value(int(lx.srt[i])), srch) >= 0
+	})
+	if i < len(lx.srt) && bytes.Equal(lx.value(int(lx.srt[i])), srch) {
+		return int(lx.srt[i])
+	}
+	return -1
+}
+
+// ID2Str returns a value with a provided ID. In case
+// the ID is out of range, an empty string is returned.
+func (lx *lexicon) ID2Str(ident int) string {
+	if ident < 0 || ident >= len(lx.idx) {
+		return ""
+	}
+	return string(lx.value(ident))
+}
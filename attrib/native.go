@@ -0,0 +1,288 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package attrib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// structRangeItemSize returns size (in bytes) of a single
+// structure range record in a [struct].rng file.
+func structRangeItemSize(structType string) int {
+	switch structType {
+	case "file64", "map64":
+		return 16
+	}
+	return 8
+}
+
+// deltaTextTypes lists Manatee text encodings (the part of an attribute
+// TYPE after "_", e.g. "FD" in "FD_FD") storing [attr].text as
+// a delta-compressed bit stream along with [attr].text.seg.
+var deltaTextTypes = map[string]bool{
+	"MD":  true,
+	"FD":  true,
+	"MGD": true,
+	"FGD": true,
+}
+
+// isPlainTextType tells whether a positional attribute type
+// stores its text (i.e. the sequence of lexicon IDs) as a plain
+// array of int32 values. Delta-compressed types (including
+// the default one, i.e. MD_MD) are not supported by the native backend.
+func isPlainTextType(attrType string) bool {
+	tmp := strings.SplitN(attrType, "_", 2)
+	return len(tmp) == 2 && !deltaTextTypes[tmp[1]]
+}
+
+// NativeCorpus is a pure Go corpus reader accessing
+// compiled Manatee data files directly.
+// Please note that the type is not thread-safe.
+type NativeCorpus struct {
+	regPath   string
+	conf      *registryConf
+	lexicons  map[string]*lexicon
+	dataFiles map[string]*os.File
+}
+
+func (nc *NativeCorpus) dataPath(name string) string {
+	return filepath.Join(nc.conf.path, name)
+}
+
+// StructSize returns a number of occurences of a specific structure
+func (nc *NativeCorpus) StructSize(name string) (int, error) {
+	strct, ok := nc.conf.structs[name]
+	if !ok {
+		return -1, fmt.Errorf("structure %s not found in %s", name, nc.regPath)
+	}
+	st, err := os.Stat(nc.dataPath(name + ".rng"))
+	if err != nil {
+		return -1, err
+	}
+	itemSize := int64(structRangeItemSize(strct.typ))
+	if st.Size()%itemSize != 0 {
+		return -1, fmt.Errorf("invalid size of range file for structure %s", name)
+	}
+	return int(st.Size() / itemSize), nil
+}
+
+func (nc *NativeCorpus) findAttrConf(name string) (attrConf, error) {
+	if tmp := strings.SplitN(name, ".", 2); len(tmp) == 2 {
+		strct, ok := nc.conf.structs[tmp[0]]
+		if !ok {
+			return attrConf{}, fmt.Errorf("structure %s not found in %s", tmp[0], nc.regPath)
+		}
+		attr, ok := strct.attrs[tmp[1]]
+		if !ok {
+			return attrConf{}, fmt.Errorf("attribute %s not found in %s", name, nc.regPath)
+		}
+		return attr, nil
+	}
+	attr, ok := nc.conf.attrs[name]
+	if !ok {
+		return attrConf{}, fmt.Errorf("attribute %s not found in %s", name, nc.regPath)
+	}
+	return attr, nil
+}
+
+// Attr loads lexicon of a positional or structural (e.g. "s.id")
+// attribute. Dynamic attributes are not supported.
+func (nc *NativeCorpus) Attr(name string) (PosAttr, error) {
+	conf, err := nc.findAttrConf(name)
+	if err != nil {
+		return nil, err
+	}
+	if conf.dynamic {
+		return nil, fmt.Errorf("dynamic attribute %s is not supported by the native backend", name)
+	}
+	lex, err := loadLexicon(nc.dataPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lexicon of %s: %s", name, err)
+	}
+	return &NativePosAttr{lex: lex}, nil
+}
+
+// StructSpan returns token positions covered by
+// the idx-th structure of a specified name.
+func (nc *NativeCorpus) StructSpan(name string, idx int) (Span, error) {
+	strct, ok := nc.conf.structs[name]
+	if !ok {
+		return Span{}, fmt.Errorf("structure %s not found in %s", name, nc.regPath)
+	}
+	if idx < 0 {
+		return Span{}, fmt.Errorf("invalid structure index %d", idx)
+	}
+	file, err := nc.dataFile(name + ".rng")
+	if err != nil {
+		return Span{}, err
+	}
+	itemSize := structRangeItemSize(strct.typ)
+	buff := make([]byte, itemSize)
+	if _, err := file.ReadAt(buff, int64(idx*itemSize)); err != nil {
+		return Span{}, fmt.Errorf("failed to read range of %s #%d: %s", name, idx, err)
+	}
+	if itemSize == 16 {
+		return Span{
+			Beg: int(binary.LittleEndian.Uint64(buff)),
+			End: int(binary.LittleEndian.Uint64(buff[8:])),
+		}, nil
+	}
+	return Span{
+		Beg: int(binary.LittleEndian.Uint32(buff)),
+		End: int(binary.LittleEndian.Uint32(buff[4:])),
+	}, nil
+}
+
+// dataFile returns a (cached) open data file (e.g. [struct].rng)
+func (nc *NativeCorpus) dataFile(name string) (*os.File, error) {
+	if file, ok := nc.dataFiles[name]; ok {
+		return file, nil
+	}
+	file, err := os.Open(nc.dataPath(name))
+	if err != nil {
+		return nil, err
+	}
+	nc.dataFiles[name] = file
+	return file, nil
+}
+
+// attrLexicon returns a (cached) lexicon of a positional attribute
+func (nc *NativeCorpus) attrLexicon(name string) (*lexicon, error) {
+	if lex, ok := nc.lexicons[name]; ok {
+		return lex, nil
+	}
+	lex, err := loadLexicon(nc.dataPath(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load lexicon of %s: %s", name, err)
+	}
+	nc.lexicons[name] = lex
+	return lex, nil
+}
+
+// attrText returns a (cached) open text file of a positional attribute.
+// Besides the attribute type, a presence of the [attr].text.seg file
+// is checked as it is written along with delta-compressed texts only.
+func (nc *NativeCorpus) attrText(attrName string) (*os.File, error) {
+	name := attrName + ".text"
+	if _, ok := nc.dataFiles[name]; !ok {
+		if _, err := os.Stat(nc.dataPath(name + ".seg")); err == nil {
+			return nil, fmt.Errorf("text of attribute %s is delta-compressed which is not supported by the native backend", attrName)
+		}
+	}
+	return nc.dataFile(name)
+}
+
+// Tokens returns values of a positional attribute at positions
+// of a provided span. Only attributes with uncompressed text
+// are supported (see isPlainTextType).
+func (nc *NativeCorpus) Tokens(attrName string, span Span) ([]string, error) {
+	conf, ok := nc.conf.attrs[attrName]
+	if !ok {
+		return nil, fmt.Errorf("attribute %s not found in %s", attrName, nc.regPath)
+	}
+	if conf.dynamic || !isPlainTextType(conf.typ) {
+		return nil, fmt.Errorf("text of attribute %s (type: '%s') is not supported by the native backend", attrName, conf.typ)
+	}
+	if span.End < span.Beg || span.Beg < 0 {
+		return nil, fmt.Errorf("invalid position range [%d, %d)", span.Beg, span.End)
+	}
+	lex, err := nc.attrLexicon(attrName)
+	if err != nil {
+		return nil, err
+	}
+	file, err := nc.attrText(attrName)
+	if err != nil {
+		return nil, err
+	}
+	buff := make([]byte, 4*span.Len())
+	if _, err := file.ReadAt(buff, int64(4*span.Beg)); err != nil {
+		return nil, fmt.Errorf("failed to read text of %s: %s", attrName, err)
+	}
+	ans := make([]string, span.Len())
+	for i := range ans {
+		ans[i] = lex.ID2Str(int(binary.LittleEndian.Uint32(buff[4*i:])))
+	}
+	return ans, nil
+}
+
+// StructText returns values of a positional attribute (separated by spaces)
+// covered by structures first...last (both inclusive).
+// Only attributes with uncompressed text are supported.
+func (nc *NativeCorpus) StructText(structName string, attrName string, first int, last int) (string, error) {
+	firstSpan, err := nc.StructSpan(structName, first)
+	if err != nil {
+		return "", err
+	}
+	lastSpan, err := nc.StructSpan(structName, last)
+	if err != nil {
+		return "", err
+	}
+	tokens, err := nc.Tokens(attrName, firstSpan.Join(lastSpan))
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(tokens, " "), nil
+}
+
+// Close closes all the data files opened by the corpus
+func (nc *NativeCorpus) Close() error {
+	var ans error
+	for name, file := range nc.dataFiles {
+		if err := file.Close(); err != nil && ans == nil {
+			ans = err
+		}
+		delete(nc.dataFiles, name)
+	}
+	return ans
+}
+
+// NativePosAttr is a pure Go implementation of attribute
+// lexicon access.
+type NativePosAttr struct {
+	lex *lexicon
+}
+
+// Str2ID transforms a string value of the attribute
+// to its numeric form (= an index).
+func (npa *NativePosAttr) Str2ID(value string) int {
+	return npa.lex.Str2ID(value)
+}
+
+// ID2Str transforms a numeric identifier of the attribute
+// to its original string value
+func (npa *NativePosAttr) ID2Str(value int) string {
+	return npa.lex.ID2Str(value)
+}
+
+// OpenNativeCorpus is a factory function creating
+// a native (i.e. Manatee-free) corpus reader.
+func OpenNativeCorpus(path string) (*NativeCorpus, error) {
+	conf, err := loadRegistry(path)
+	if err != nil {
+		return nil, err
+	}
+	return &NativeCorpus{
+		regPath:   path,
+		conf:      conf,
+		lexicons:  make(map[string]*lexicon),
+		dataFiles: make(map[string]*os.File),
+	}, nil
+}
@@ -0,0 +1,277 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package attrib
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeInt32File(path string, values []uint32) {
+	data := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint32(data[4*i:], v)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		panic(err)
+	}
+}
+
+// createTestLexicon writes [prefix].lex, [prefix].lex.idx and
+// (if withSrt is true) [prefix].lex.srt files
+func createTestLexicon(prefix string, values []string, withSrt bool) {
+	data := make([]byte, 0, 100)
+	idx := make([]uint32, len(values))
+	srt := make([]uint32, len(values))
+	for i, v := range values {
+		idx[i] = uint32(len(data))
+		srt[i] = uint32(i)
+		data = append(data, []byte(v)...)
+		data = append(data, 0)
+	}
+	sort.Slice(srt, func(i, j int) bool { return values[srt[i]] < values[srt[j]] })
+	if err := ioutil.WriteFile(prefix+".lex", data, 0644); err != nil {
+		panic(err)
+	}
+	writeInt32File(prefix+".lex.idx", idx)
+	if withSrt {
+		writeInt32File(prefix+".lex.srt", srt)
+	}
+}
+
+// createTestCorpus creates a registry file and data files
+// of a corpus with three sentences.
+func createTestCorpus(withSrt bool) (string, string) {
+	rootDir, err := ioutil.TempDir("", "ictools-attrib")
+	if err != nil {
+		panic(err)
+	}
+	dataDir := filepath.Join(rootDir, "data")
+	if err := os.Mkdir(dataDir, 0755); err != nil {
+		panic(err)
+	}
+	regPath := filepath.Join(rootDir, "testcorp")
+	reg := fmt.Sprintf(`# test corpus
+NAME "Test corpus"
+PATH "%s/"
+ATTRIBUTE word {
+	TYPE "MD_MI"
+}
+ATTRIBUTE lemma {
+	DYNAMIC "lemma_fn"
+}
+STRUCTURE s {
+	ATTRIBUTE id {
+		LABEL "sentence {ID}"
+	}
+	TYPE "file32"
+}
+`, dataDir)
+	if err := ioutil.WriteFile(regPath, []byte(reg), 0644); err != nil {
+		panic(err)
+	}
+	createTestLexicon(filepath.Join(dataDir, "s.id"), []string{"cs:a:1", "cs:a:2", "cs:b:1"}, withSrt)
+	createTestLexicon(filepath.Join(dataDir, "word"), []string{"Hello", "world", "!"}, withSrt)
+	writeInt32File(filepath.Join(dataDir, "word.text"), []uint32{0, 1, 2})
+	writeInt32File(filepath.Join(dataDir, "s.rng"), []uint32{0, 2, 2, 3, 3, 3})
+	return rootDir, regPath
+}
+
+func TestParseRegistry(t *testing.T) {
+	conf, err := parseRegistry(`
+PATH /corpora/data/foo # a comment
+ATTRIBUTE "word"
+STRUCTURE doc {
+	ATTRIBUTE id
+	ATTRIBUTE "title" {
+		TYPE "MD_MI"
+	}
+}`)
+	assert.Nil(t, err)
+	assert.Equal(t, "/corpora/data/foo", conf.path)
+	assert.Contains(t, conf.attrs, "word")
+	assert.Contains(t, conf.structs["doc"].attrs, "id")
+	assert.Equal(t, "MD_MI", conf.structs["doc"].attrs["title"].typ)
+}
+
+func TestParseRegistryUnterminatedBlock(t *testing.T) {
+	_, err := parseRegistry("PATH /foo\nSTRUCTURE s {\n ATTRIBUTE id\n")
+	assert.Error(t, err)
+}
+
+func TestParseRegistryMissingPath(t *testing.T) {
+	_, err := parseRegistry("ATTRIBUTE word\n")
+	assert.Error(t, err)
+}
+
+func TestNativeAttr(t *testing.T) {
+	rootDir, regPath := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+
+	corp, err := Open(BackendNative, regPath)
+	assert.Nil(t, err)
+	attr, err := corp.Attr("s.id")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, attr.Str2ID("cs:a:1"))
+	assert.Equal(t, 2, attr.Str2ID("cs:b:1"))
+	assert.Equal(t, -1, attr.Str2ID("cs:c:1"))
+	assert.Equal(t, "cs:a:2", attr.ID2Str(1))
+	assert.Equal(t, "", attr.ID2Str(3))
+	assert.Equal(t, "", attr.ID2Str(-1))
+}
+
+func TestNativeAttrWithoutSortIndex(t *testing.T) {
+	rootDir, regPath := createTestCorpus(false)
+	defer os.RemoveAll(rootDir)
+
+	corp, err := OpenNativeCorpus(regPath)
+	assert.Nil(t, err)
+	attr, err := corp.Attr("word")
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attr.Str2ID("!"))
+	assert.Equal(t, 1, attr.Str2ID("world"))
+}
+
+func TestNativeAttrNotSupported(t *testing.T) {
+	rootDir, regPath := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+
+	corp, err := OpenNativeCorpus(regPath)
+	assert.Nil(t, err)
+	_, err = corp.Attr("lemma")
+	assert.Error(t, err)
+	_, err = corp.Attr("s.foo")
+	assert.Error(t, err)
+	_, err = corp.Attr("doc.id")
+	assert.Error(t, err)
+}
+
+func TestNativeStructSize(t *testing.T) {
+	rootDir, regPath := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+
+	corp, err := OpenNativeCorpus(regPath)
+	assert.Nil(t, err)
+	size, err := corp.StructSize("s")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, size)
+	_, err = corp.StructSize("doc")
+	assert.Error(t, err)
+}
+
+func TestFindRegistryFileViaEnv(t *testing.T) {
+	rootDir, _ := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+
+	os.Setenv(registryEnvVar, "/nonexistent"+string(filepath.ListSeparator)+rootDir)
+	defer os.Unsetenv(registryEnvVar)
+	path, err := findRegistryFile("testcorp")
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(rootDir, "testcorp"), path)
+}
+
+func TestNativeStructText(t *testing.T) {
+	rootDir, regPath := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+
+	corp, err := OpenNativeCorpus(regPath)
+	assert.Nil(t, err)
+	text, err := corp.StructText("s", "word", 0, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello world", text)
+	text, err = corp.StructText("s", "word", 0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello world !", text)
+	text, err = corp.StructText("s", "word", 2, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, "", text)
+	_, err = corp.StructText("s", "word", 3, 3)
+	assert.Error(t, err)
+	_, err = corp.StructText("s", "lemma", 0, 0)
+	assert.Error(t, err)
+}
+
+func TestNativeStructSpanAndTokens(t *testing.T) {
+	rootDir, regPath := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+
+	corp, err := OpenNativeCorpus(regPath)
+	assert.Nil(t, err)
+	span, err := corp.StructSpan("s", 1)
+	assert.Nil(t, err)
+	assert.Equal(t, Span{Beg: 2, End: 3}, span)
+	assert.Equal(t, 1, span.Len())
+	_, err = corp.StructSpan("s", 3)
+	assert.Error(t, err)
+	_, err = corp.StructSpan("doc", 0)
+	assert.Error(t, err)
+
+	tokens, err := corp.Tokens("word", Span{Beg: 1, End: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"world", "!"}, tokens)
+	_, err = corp.Tokens("word", Span{Beg: 2, End: 4})
+	assert.Error(t, err)
+}
+
+func TestNativeDataFilesReused(t *testing.T) {
+	rootDir, regPath := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+
+	corp, err := OpenNativeCorpus(regPath)
+	assert.Nil(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = corp.StructText("s", "word", 0, 1)
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 2, len(corp.dataFiles))
+	assert.Nil(t, corp.Close())
+	assert.Equal(t, 0, len(corp.dataFiles))
+	text, err := corp.StructText("s", "word", 0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello world !", text)
+	assert.Nil(t, corp.Close())
+}
+
+func TestIsPlainTextType(t *testing.T) {
+	assert.True(t, isPlainTextType("MD_MI"))
+	assert.False(t, isPlainTextType("FD_FD"))
+	assert.False(t, isPlainTextType("FD_FGD"))
+	assert.False(t, isPlainTextType("MD_MGD"))
+	assert.False(t, isPlainTextType("MD_MD"))
+	assert.False(t, isPlainTextType(""))
+	assert.False(t, isPlainTextType("default"))
+}
+
+func TestNativeTokensDeltaCompressed(t *testing.T) {
+	rootDir, regPath := createTestCorpus(true)
+	defer os.RemoveAll(rootDir)
+	writeInt32File(filepath.Join(rootDir, "data", "word.text.seg"), []uint32{0})
+
+	corp, err := OpenNativeCorpus(regPath)
+	assert.Nil(t, err)
+	_, err = corp.Tokens("word", Span{Beg: 0, End: 2})
+	assert.Error(t, err)
+	_, err = corp.StructText("s", "word", 0, 0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,36 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+//go:build nomanatee
+
+// Package attrib contains types and functions used to access
+// corpus data. This variant is built without the Manatee C library
+// so only the native backend is available.
+package attrib
+
+import (
+	"fmt"
+)
+
+const (
+	// DefaultBackend is the backend used when no explicit
+	// choice is made. Without Manatee, only the native one works.
+	DefaultBackend = BackendNative
+)
+
+func openManateeCorpus(path string) (Corpus, error) {
+	return nil, fmt.Errorf("ictools built without Manatee support (use the '%s' backend)", BackendNative)
+}
@@ -0,0 +1,230 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package attrib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	registryEnvVar = "MANATEE_REGISTRY"
+)
+
+// regToken is a single token of a registry file
+type regToken struct {
+	value  string
+	quoted bool
+}
+
+func (t regToken) isBlockStart() bool {
+	return !t.quoted && t.value == "{"
+}
+
+func (t regToken) isBlockEnd() bool {
+	return !t.quoted && t.value == "}"
+}
+
+// regNode is a "KEY value { ... }" record of a registry file
+type regNode struct {
+	key      string
+	value    string
+	children []regNode
+}
+
+func (n regNode) childValue(key string) string {
+	for _, ch := range n.children {
+		if ch.key == key {
+			return ch.value
+		}
+	}
+	return ""
+}
+
+// attrConf describes a (positional or structural) attribute
+// as defined in a registry file
+type attrConf struct {
+	name    string
+	typ     string
+	dynamic bool
+}
+
+// structConf describes a structure as defined in a registry file
+type structConf struct {
+	name  string
+	typ   string
+	attrs map[string]attrConf
+}
+
+// registryConf contains the parts of a Manatee registry file
+// the native backend needs to locate corpus data files.
+type registryConf struct {
+	path    string
+	attrs   map[string]attrConf
+	structs map[string]structConf
+}
+
+func tokenizeRegistry(src string) ([]regToken, error) {
+	ans := make([]regToken, 0, 100)
+	runes := []rune(src)
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		case c == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case c == '{' || c == '}':
+			ans = append(ans, regToken{value: string(c)})
+		case c == '"':
+			var bld strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				bld.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, fmt.Errorf("unterminated string in registry file")
+			}
+			ans = append(ans, regToken{value: bld.String(), quoted: true})
+		default:
+			start := i
+			for i < len(runes) && !strings.ContainsRune(" \t\r\n{}\"#", runes[i]) {
+				i++
+			}
+			ans = append(ans, regToken{value: string(runes[start:i])})
+			i--
+		}
+	}
+	return ans, nil
+}
+
+// parseRegistryBlock parses a sequence of "KEY value" records (each with an
+// optional nested block) starting at position 'pos'. It returns the parsed
+// records and a position right after the block.
+func parseRegistryBlock(tokens []regToken, pos int, nested bool) ([]regNode, int, error) {
+	ans := make([]regNode, 0, 10)
+	for pos < len(tokens) {
+		if tokens[pos].isBlockEnd() {
+			if !nested {
+				return nil, pos, fmt.Errorf("unexpected '}' in registry file")
+			}
+			return ans, pos + 1, nil
+		}
+		if pos+1 >= len(tokens) || tokens[pos+1].isBlockStart() || tokens[pos+1].isBlockEnd() {
+			return nil, pos, fmt.Errorf("missing value for registry key %s", tokens[pos].value)
+		}
+		node := regNode{key: strings.ToUpper(tokens[pos].value), value: tokens[pos+1].value}
+		pos += 2
+		if pos < len(tokens) && tokens[pos].isBlockStart() {
+			var err error
+			node.children, pos, err = parseRegistryBlock(tokens, pos+1, true)
+			if err != nil {
+				return nil, pos, err
+			}
+		}
+		ans = append(ans, node)
+	}
+	if nested {
+		return nil, pos, fmt.Errorf("unterminated block in registry file")
+	}
+	return ans, pos, nil
+}
+
+func newAttrConf(node regNode) attrConf {
+	return attrConf{
+		name:    node.value,
+		typ:     node.childValue("TYPE"),
+		dynamic: node.childValue("DYNAMIC") != "",
+	}
+}
+
+func parseRegistry(src string) (*registryConf, error) {
+	tokens, err := tokenizeRegistry(src)
+	if err != nil {
+		return nil, err
+	}
+	nodes, _, err := parseRegistryBlock(tokens, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	ans := &registryConf{
+		attrs:   make(map[string]attrConf),
+		structs: make(map[string]structConf),
+	}
+	for _, node := range nodes {
+		switch node.key {
+		case "PATH":
+			ans.path = node.value
+		case "ATTRIBUTE":
+			ans.attrs[node.value] = newAttrConf(node)
+		case "STRUCTURE":
+			strct := structConf{
+				name:  node.value,
+				typ:   node.childValue("TYPE"),
+				attrs: make(map[string]attrConf),
+			}
+			for _, ch := range node.children {
+				if ch.key == "ATTRIBUTE" {
+					strct.attrs[ch.value] = newAttrConf(ch)
+				}
+			}
+			ans.structs[node.value] = strct
+		}
+	}
+	if ans.path == "" {
+		return nil, fmt.Errorf("missing PATH in registry file")
+	}
+	return ans, nil
+}
+
+// findRegistryFile returns a path to a registry file. Just like Manatee,
+// it accepts either a path to the file or a bare corpus name which is
+// then searched in directories listed in the MANATEE_REGISTRY variable.
+func findRegistryFile(path string) (string, error) {
+	if _, err := os.Stat(path); err == nil || strings.ContainsRune(path, os.PathSeparator) {
+		return path, err
+	}
+	for _, dir := range filepath.SplitList(os.Getenv(registryEnvVar)) {
+		regPath := filepath.Join(dir, path)
+		if _, err := os.Stat(regPath); err == nil {
+			return regPath, nil
+		}
+	}
+	return "", fmt.Errorf("corpus registry %s not found", path)
+}
+
+func loadRegistry(path string) (*registryConf, error) {
+	regPath, err := findRegistryFile(path)
+	if err != nil {
+		return nil, err
+	}
+	src, err := ioutil.ReadFile(regPath)
+	if err != nil {
+		return nil, err
+	}
+	conf, err := parseRegistry(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse registry %s: %s", regPath, err)
+	}
+	return conf, nil
+}
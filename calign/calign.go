@@ -25,6 +25,7 @@ package calign
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
@@ -34,8 +35,38 @@ import (
 )
 
 const (
-	quoteStyleSingle = 1
-	quoteStyleDouble = 2
+	xtargetsAttr = "xtargets="
+
+	statusAttr = "status="
+
+	// emptyResultMinLines specifies a minimum number of non-empty
+	// lines for which a source file without any alignment found
+	// is considered invalid (e.g. a wrong format).
+	emptyResultMinLines = 10
+
+	// InputFormatXCES is the default line-based (and fast) reader
+	// of XCES alignment files expecting one <link> element per line.
+	InputFormatXCES = "xces"
+
+	// InputFormatXML is a real XML parser reading XCES/cesAlign
+	// documents regardless of their formatting (see XMLProcessor).
+	InputFormatXML = "xml"
+
+	// InputFormatHunalign is hunalign's ladder output
+	// (see HunalignProcessor).
+	InputFormatHunalign = "hunalign"
+
+	// InputFormatBisentence is hunalign's bisentence (-text)
+	// output (see HunalignProcessor).
+	InputFormatBisentence = "bisentence"
+
+	// InputFormatVecalign is vecalign's output
+	// (see VecalignProcessor).
+	InputFormatVecalign = "vecalign"
+
+	// InputFormatIndex is a generic index based sentence alignment
+	// (see IndexProcessor).
+	InputFormatIndex = "index"
 )
 
 // AttribMapper is a general type allowing transformation
@@ -47,33 +78,30 @@ type AttribMapper interface {
 	ID2Str(ident int) string
 }
 
+// InputReader is implemented by all the readers of supported
+// alignment source formats. Process transforms the source
+// data into a stream of numeric mappings (in the order they
+// appear in the source). The sourceName argument is used just
+// for logging. ProcessFile does the same with a file.
+type InputReader interface {
+	Process(src io.Reader, sourceName string, bufferSize int, onItem func(item mapping.Mapping, i int)) error
+	ProcessFile(file *os.File, bufferSize int, onItem func(item mapping.Mapping, i int)) error
+}
+
 // Processor represents an object used
 // to process an alignment XML input file.
 type Processor struct {
 	attr1        AttribMapper
 	attr2        AttribMapper
-	valPrefix    string
-	valSuffix    string
-	valOffset    int
 	lastPos      int
 	lastPivotPos int
 }
 
 // NewProcessor creates a new instance of Processor
-func NewProcessor(attr1 AttribMapper, attr2 AttribMapper, quoteStyle int) *Processor {
-	valPrefix := "xtargets='"
-	valSuffix := "'"
-	if quoteStyle == quoteStyleDouble {
-		valPrefix = "xtargets=\""
-		valSuffix = "\""
-	}
-
+func NewProcessor(attr1 AttribMapper, attr2 AttribMapper) *Processor {
 	return &Processor{
 		attr1:        attr1,
 		attr2:        attr2,
-		valPrefix:    valPrefix,
-		valSuffix:    valSuffix,
-		valOffset:    len(valPrefix),
 		lastPos:      0,
 		lastPivotPos: 0,
 	}
@@ -113,71 +141,106 @@ func (p *Processor) processColElm(value string, attr AttribMapper, lineNum int)
 // parseLine accepts lines of the form:
 // <link type='1-1' xtargets='pl:_ACQUIS:jrc21959A1006_01:28:1;cs:_ACQUIS:jrc21959A1006_01:28:1' status='auto'/>
 // other lines are ignored (i.e. an empty string is returned).
+// Both single and double quotes are accepted (the style is
+// detected for each line separately).
 // Devel note: we try to avoid regexp here as it is quite slow compared with
 // Python's or Perl's regexp engines (tested).
 
 func (p *Processor) parseLine(src string) string {
-	startIdx := strings.Index(src, p.valPrefix)
-	if startIdx > -1 {
-		endIdx := strings.Index(src[startIdx+p.valOffset:], p.valSuffix)
+	return p.parseAttrValue(src, xtargetsAttr)
+}
+
+// parseAttrValue finds a (single or double) quoted value of an attribute
+// specified by its prefix (e.g. "status=") in a line.
+// If nothing is found, an empty string is returned.
+func (p *Processor) parseAttrValue(src string, attrPrefix string) string {
+	startIdx := strings.Index(src, attrPrefix)
+	if startIdx > -1 && startIdx+len(attrPrefix) < len(src) {
+		valIdx := startIdx + len(attrPrefix)
+		quote := src[valIdx]
+		if quote != '\'' && quote != '"' {
+			return ""
+		}
+		endIdx := strings.IndexByte(src[valIdx+1:], quote)
 		if endIdx > -1 {
-			return src[startIdx+p.valOffset : startIdx+p.valOffset+endIdx]
+			return src[valIdx+1 : valIdx+1+endIdx]
 		}
 	}
 	return ""
 }
 
+// processXTargets transforms a value of the 'xtargets' attribute
+// (e.g. "pl:foo:1:1 pl:foo:1:2;cs:foo:1:1") into a numeric mapping
+func (p *Processor) processXTargets(srch string, lineNum int) (mapping.Mapping, error) {
+	aligned := strings.Split(srch, ";")
+	if len(aligned) != 2 {
+		return mapping.Mapping{}, fmt.Errorf("skipping invalid mapping on line %d", lineNum+1)
+	}
+	l1, err1 := p.processColElm(aligned[0], p.attr1, lineNum)
+	if err1 != nil {
+		return mapping.Mapping{}, err1
+	}
+	l2, err2 := p.processColElm(aligned[1], p.attr2, lineNum)
+	if err2 != nil {
+		return mapping.Mapping{}, err2
+	}
+	p.lastPos = l1.Last
+	if l2.Last > -1 {
+		p.lastPivotPos = l2.Last
+	}
+	return mapping.Mapping{From: l1, To: l2}, nil
+}
+
 // processLine parses a single line of XML input file
 // any other xml element is ignored
 func (p *Processor) processLine(line string, lineNum int) (mapping.Mapping, error) {
 	srch := p.parseLine(line)
 	if len(srch) > 0 {
-		aligned := strings.Split(srch, ";")
-		if len(aligned) > 2 {
-			return mapping.Mapping{}, fmt.Errorf("skipping invalid mapping on line %d", lineNum+1)
-		}
-		l1, err1 := p.processColElm(aligned[0], p.attr1, lineNum)
-		if err1 != nil {
-			return mapping.Mapping{}, err1
-		}
-		l2, err2 := p.processColElm(aligned[1], p.attr2, lineNum)
-		if err2 != nil {
-			return mapping.Mapping{}, err2
-		}
-		p.lastPos = l1.Last
-		if l2.Last > -1 {
-			p.lastPivotPos = l2.Last
+		mp, err := p.processXTargets(srch, lineNum)
+		if err == nil {
+			mp.Meta.Status = p.parseAttrValue(line, statusAttr)
 		}
-		return mapping.Mapping{l1, l2, false}, nil
+		return mp, err
 	}
 	return mapping.Mapping{}, NewIgnorableError("skipping non-alignment line %d", lineNum)
 }
 
-// ProcessFile reads an input XML file containing mappings between
+// Process reads input XML data containing mappings between
 // structures (typically <s> for a sentence) of two languages and
 // transforms them into a numeric representation based on internal
 // identifiers used by Manatee.
+// In case the data contain many non-empty lines but none of them
+// is recognized as an alignment, NoAlignmentError is returned.
+// The sourceName argument is used just for logging.
 // The function does not print anything to stdout.
-func (p *Processor) ProcessFile(file *os.File, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
-	reader := bufio.NewScanner(file)
+func (p *Processor) Process(src io.Reader, sourceName string, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	reader := bufio.NewScanner(src)
 	reader.Buffer(make([]byte, bufio.MaxScanTokenSize), bufferSize)
 	var i int
 	count := 0
+	numNonEmpty := 0
+	numAlignLines := 0
 	for i = 0; reader.Scan(); i++ {
 		if i%1000000 == 0 {
 			log.Printf("INFO: Read %dm lines", i/1000000)
 		}
-		mp, err := p.processLine(reader.Text(), i)
+		line := reader.Text()
+		if strings.TrimSpace(line) != "" {
+			numNonEmpty++
+		}
+		mp, err := p.processLine(line, i)
 		if err == nil {
 			onItem(mp, count)
 			count++
+			numAlignLines++
 
 		} else {
 			switch err.(type) {
 			case IgnorableError:
 				log.Print("INFO: ", err)
 			default:
-				log.Printf("ERROR: %s (file: %s)", err, filepath.Base(file.Name()))
+				numAlignLines++
+				log.Printf("ERROR: %s (file: %s)", err, sourceName)
 			}
 		}
 	}
@@ -185,5 +248,34 @@ func (p *Processor) ProcessFile(file *os.File, bufferSize int, onItem func(item
 	if err != nil {
 		return NewFileImportError(err, i)
 	}
+	if numAlignLines == 0 && numNonEmpty >= emptyResultMinLines {
+		return NewNoAlignmentError(sourceName, numNonEmpty)
+	}
 	return nil
 }
+
+// ProcessFile reads an input XML file (see Process())
+func (p *Processor) ProcessFile(file *os.File, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	return p.Process(file, filepath.Base(file.Name()), bufferSize, onItem)
+}
+
+// NewInputReader creates a reader of a specified input format
+// (see InputFormat* constants). Structure ID lists (ids1, ids2) are
+// used only by sentence aligner formats (nil means that sentence
+// index equals structure position). For an unknown format,
+// UnknownFormatError is returned.
+func NewInputReader(format string, attr1 AttribMapper, attr2 AttribMapper, ids1 []string, ids2 []string) (InputReader, error) {
+	switch format {
+	case InputFormatXCES:
+		return NewProcessor(attr1, attr2), nil
+	case InputFormatXML:
+		return NewXMLProcessor(attr1, attr2), nil
+	case InputFormatHunalign, InputFormatBisentence:
+		return NewHunalignProcessor(attr1, attr2, ids1, ids2, format == InputFormatBisentence), nil
+	case InputFormatVecalign:
+		return NewVecalignProcessor(attr1, attr2, ids1, ids2), nil
+	case InputFormatIndex:
+		return NewIndexProcessor(attr1, attr2, ids1, ids2), nil
+	}
+	return nil, NewUnknownFormatError(format)
+}
@@ -110,6 +110,11 @@ func (ma *MockAttr2) ID2Str(ident int) string {
 	}
 }
 
+func withStatus(m mapping.Mapping, status string) mapping.Mapping {
+	m.Meta.Status = status
+	return m
+}
+
 func createProcessor() *Processor {
 	return &Processor{
 		attr1: &MockAttr1{},
@@ -118,11 +123,7 @@ func createProcessor() *Processor {
 }
 
 func createFullProcessor() *Processor {
-	ans := createProcessor()
-	ans.valPrefix = "xtargets='"
-	ans.valSuffix = "'"
-	ans.valOffset = len(ans.valPrefix)
-	return ans
+	return NewProcessor(&MockAttr1{}, &MockAttr2{})
 }
 
 func TestProcessColElementSingle(t *testing.T) {
@@ -185,23 +186,38 @@ func TestProcessColElementNonExistentBothSides(t *testing.T) {
 func TestParseLine(t *testing.T) {
 	line := "<link type='1-1' xtargets='pl:_ACQUIS:jrc2;cs:_ACQUIS:jrc3' status='auto'/>"
 	p := createProcessor()
-	p.valPrefix = "ets='"
-	p.valSuffix = "'"
-	p.valOffset = len("ets='")
 	v := p.parseLine(line)
 	assert.Equal(t, "pl:_ACQUIS:jrc2;cs:_ACQUIS:jrc3", v)
 }
 
+func TestParseLineDoubleQuotes(t *testing.T) {
+	line := "<link type=\"1-1\" xtargets=\"pl:_ACQUIS:jrc2;cs:_ACQUIS:jrc3\" status=\"auto\"/>"
+	p := createProcessor()
+	v := p.parseLine(line)
+	assert.Equal(t, "pl:_ACQUIS:jrc2;cs:_ACQUIS:jrc3", v)
+}
+
+func TestParseLineMixedQuotes(t *testing.T) {
+	line := "<link type=\"1-1\" xtargets='pl:\"x\";cs:y' status=\"auto\"/>"
+	p := createProcessor()
+	v := p.parseLine(line)
+	assert.Equal(t, "pl:\"x\";cs:y", v)
+}
+
 func TestParseLineInvalid(t *testing.T) {
 	line := "<link type='1-1' xstuff='pl:_ACQUIS:jrc2;cs:_ACQUIS:jrc3' status='auto'/>"
 	p := createProcessor()
-	p.valPrefix = "ets='"
-	p.valSuffix = "'"
-	p.valOffset = len("ets='")
 	v := p.parseLine(line)
 	assert.Equal(t, "", v)
 }
 
+func TestParseLineUnquoted(t *testing.T) {
+	p := createProcessor()
+	assert.Equal(t, "", p.parseLine("<link xtargets=foo;bar />"))
+	assert.Equal(t, "", p.parseLine("<link xtargets="))
+	assert.Equal(t, "", p.parseLine("<link xtargets='foo;bar />"))
+}
+
 func TestProcessLine(t *testing.T) {
 	line := "<link type='1-1' xtargets='foo:1 foo:2;bar:1 bar:3' status='auto'/>"
 	p := createFullProcessor()
@@ -282,27 +298,19 @@ func TestProcessLineRangeMissing2(t *testing.T) {
 func TestNewProcessor(t *testing.T) {
 	attr1 := &MockAttr1{}
 	attr2 := &MockAttr2{}
-	p := NewProcessor(attr1, attr2, quoteStyleSingle)
-	assert.Equal(t, p.valPrefix, "xtargets='")
-	assert.Equal(t, p.valSuffix, "'")
-	assert.Equal(t, p.valOffset, len("xtargets='"))
+	p := NewProcessor(attr1, attr2)
 	assert.Equal(t, p.lastPos, 0)
 	assert.Equal(t, p.lastPivotPos, 0)
 	assert.Equal(t, p.attr1, attr1)
 	assert.Equal(t, p.attr2, attr2)
 }
 
-func TestNewProcessorDoubleQ(t *testing.T) {
-	attr1 := &MockAttr1{}
-	attr2 := &MockAttr2{}
-	p := NewProcessor(attr1, attr2, quoteStyleDouble)
-	assert.Equal(t, p.valPrefix, "xtargets=\"")
-	assert.Equal(t, p.valSuffix, "\"")
-	assert.Equal(t, p.valOffset, len("xtargets=\""))
-	assert.Equal(t, p.lastPos, 0)
-	assert.Equal(t, p.lastPivotPos, 0)
-	assert.Equal(t, p.attr1, attr1)
-	assert.Equal(t, p.attr2, attr2)
+func TestProcessLineDoubleQ(t *testing.T) {
+	line := "<link type=\"1-1\" xtargets=\"foo:1 foo:2;bar:1 bar:3\" status=\"auto\"/>"
+	p := createFullProcessor()
+	m, err := p.processLine(line, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, withStatus(mapping.NewMapping(1, 2, 1, 3), mapping.StatusAuto), m)
 }
 
 func TestProcessFile(t *testing.T) {
@@ -356,3 +364,40 @@ func TestProcessFileError(t *testing.T) {
 	})
 	assert.Nil(t, err)
 }
+
+func TestProcessFileMixedQuotes(t *testing.T) {
+	valData := []mapping.Mapping{
+		withStatus(mapping.NewMapping(0, 0, 0, 0), mapping.StatusManual),
+		withStatus(mapping.NewMapping(1, 1, 1, 1), mapping.StatusManual),
+		withStatus(mapping.NewMapping(2, 3, 2, 2), mapping.StatusAuto),
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	f, err := os.Open(filepath.Join(cwd, "..", "testdata", "foo-ids.mixed.xml"))
+	if err != nil {
+		panic(err)
+	}
+	p := createFullProcessor()
+	ans := make([]mapping.Mapping, 0, 3)
+	err = p.ProcessFile(f, 1000, func(item mapping.Mapping, i int) {
+		ans = append(ans, item)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, valData, ans)
+}
+
+func TestProcessFileNoAlignment(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	f, err := os.Open(filepath.Join(cwd, "..", "testdata", "no-links.xml"))
+	if err != nil {
+		panic(err)
+	}
+	p := createFullProcessor()
+	err = p.ProcessFile(f, 1000, func(item mapping.Mapping, i int) {})
+	assert.IsType(t, NoAlignmentError{}, err)
+}
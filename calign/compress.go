@@ -20,24 +20,25 @@ package calign
 
 import (
 	"bufio"
+	"io"
 	"log"
 	"os"
 
 	"github.com/czcorpus/ictools/mapping"
 )
 
-func mkLeftToEmpty(beg int, end int, isGap bool) mapping.Mapping {
-	if isGap {
-		return mapping.NewGapMapping(beg, end, -1, -1)
-	}
-	return mapping.NewMapping(beg, end, -1, -1)
+func mkLeftToEmpty(beg int, end int, isGap bool, meta mapping.LinkMeta) mapping.Mapping {
+	ans := mapping.NewMapping(beg, end, -1, -1)
+	ans.IsGap = isGap
+	ans.Meta = meta
+	return ans
 }
 
-func mkEmptyToRight(beg int, end int, isGap bool) mapping.Mapping {
-	if isGap {
-		return mapping.NewGapMapping(-1, -1, beg, end)
-	}
-	return mapping.NewMapping(-1, -1, beg, end)
+func mkEmptyToRight(beg int, end int, isGap bool, meta mapping.LinkMeta) mapping.Mapping {
+	ans := mapping.NewMapping(-1, -1, beg, end)
+	ans.IsGap = isGap
+	ans.Meta = meta
+	return ans
 }
 
 // compressStep decides whether 'item' should be either added to
@@ -45,23 +46,27 @@ func mkEmptyToRight(beg int, end int, isGap bool) mapping.Mapping {
 // Please note that 'currRanges' is of a little misused type here as it
 // stores no concrete mapping line we want eventually store but rather currently
 // reached non-empty ranges for left and right sizes.
+// Link metadata of merged items are combined (see mapping.LinkMeta.Combine).
 func compressStep(item *mapping.Mapping, currRanges *mapping.Mapping, gapsOnly bool, onItem func(item mapping.Mapping)) {
 	if item.To.First == -1 && (gapsOnly && item.IsGap || !gapsOnly) {
 		if currRanges.From.First == -2 {
 			currRanges.From.First = item.From.First
 			currRanges.From.Last = item.From.Last
 			currRanges.IsGap = item.IsGap
+			currRanges.Meta = item.Meta
 
 		} else {
 			currRanges.From.Last = item.From.Last
+			currRanges.Meta = currRanges.Meta.Combine(item.Meta)
 		}
 		return
 
 	} else if currRanges.From.First != -2 {
-		onItem(mkLeftToEmpty(currRanges.From.First, currRanges.From.Last, currRanges.IsGap))
+		onItem(mkLeftToEmpty(currRanges.From.First, currRanges.From.Last, currRanges.IsGap, currRanges.Meta))
 		currRanges.From.First = -2
 		currRanges.From.Last = -2
 		currRanges.IsGap = false
+		currRanges.Meta = mapping.LinkMeta{}
 		// TODO also reset From.Last
 	}
 	if item.From.First == -1 && (gapsOnly && item.IsGap || !gapsOnly) {
@@ -69,17 +74,20 @@ func compressStep(item *mapping.Mapping, currRanges *mapping.Mapping, gapsOnly b
 			currRanges.To.First = item.To.First
 			currRanges.To.Last = item.To.Last
 			currRanges.IsGap = item.IsGap
+			currRanges.Meta = item.Meta
 
 		} else {
 			currRanges.To.Last = item.To.Last
+			currRanges.Meta = currRanges.Meta.Combine(item.Meta)
 		}
 		return
 
 	} else if currRanges.To.First != -2 {
-		onItem(mkEmptyToRight(currRanges.To.First, currRanges.To.Last, currRanges.IsGap))
+		onItem(mkEmptyToRight(currRanges.To.First, currRanges.To.Last, currRanges.IsGap, currRanges.Meta))
 		currRanges.To.First = -2
 		currRanges.To.Last = -2
 		currRanges.IsGap = false
+		currRanges.Meta = mapping.LinkMeta{}
 	}
 	onItem(*item)
 }
@@ -98,17 +106,41 @@ func CompressFromChan(ch chan []mapping.Mapping, gapsOnly bool, onItem func(mapp
 	}
 
 	if currRanges.From.First != -2 {
-		onItem(mkLeftToEmpty(currRanges.From.First, currRanges.From.Last, currRanges.IsGap))
+		onItem(mkLeftToEmpty(currRanges.From.First, currRanges.From.Last, currRanges.IsGap, currRanges.Meta))
 	}
 	if currRanges.To.First != -2 {
-		onItem(mkEmptyToRight(currRanges.To.First, currRanges.To.Last, currRanges.IsGap))
+		onItem(mkEmptyToRight(currRanges.To.First, currRanges.To.Last, currRanges.IsGap, currRanges.Meta))
 	}
 }
 
 // CompressFromFile runs in the same way as CompressFromChan except that
-// the data source is a file in this case.
+// the data source is a file in this case. Invalid lines are logged
+// and skipped.
 func CompressFromFile(file *os.File, gapsOnly bool, onItem func(item mapping.Mapping)) {
-	fr := bufio.NewScanner(file)
+	err := compressLines(file, gapsOnly, onItem, func(err *mapping.ParseError) error {
+		log.Printf("ERROR: Failed to process line %d: %s", err.Line, err.Err)
+		return nil
+	})
+	if err != nil {
+		log.Print("ERROR: ", err)
+	}
+}
+
+// CompressFromReader runs in the same way as CompressFromChan except that
+// the data source is a reader of a text numeric mapping. In case of an invalid
+// line, *mapping.ParseError is returned (items read so far are already
+// passed to onItem).
+func CompressFromReader(src io.Reader, gapsOnly bool, onItem func(item mapping.Mapping)) error {
+	return compressLines(src, gapsOnly, onItem, func(err *mapping.ParseError) error {
+		return err
+	})
+}
+
+// compressLines compresses text numeric mapping lines read from src.
+// Invalid lines are passed to onError which decides whether the processing
+// should continue (nil) or end with the returned error.
+func compressLines(src io.Reader, gapsOnly bool, onItem func(item mapping.Mapping), onError func(err *mapping.ParseError) error) error {
+	fr := bufio.NewScanner(src)
 	currRanges := mapping.NewMapping(-2, -2, -2, -2) // -2 is an empty value placeholder
 
 	for i := 0; fr.Scan(); i++ {
@@ -116,15 +148,21 @@ func CompressFromFile(file *os.File, gapsOnly bool, onItem func(item mapping.Map
 		if err == nil {
 			compressStep(&item, &currRanges, gapsOnly, onItem)
 
-		} else {
-			log.Printf("ERROR: Failed to process line %d: %s", i, err)
+		} else if err != mapping.ErrComment {
+			if err := onError(&mapping.ParseError{Line: i, Err: err}); err != nil {
+				return err
+			}
 		}
 	}
+	if err := fr.Err(); err != nil {
+		return err
+	}
 
 	if currRanges.From.First != -2 {
-		onItem(mkLeftToEmpty(currRanges.From.First, currRanges.From.Last, currRanges.IsGap))
+		onItem(mkLeftToEmpty(currRanges.From.First, currRanges.From.Last, currRanges.IsGap, currRanges.Meta))
 	}
 	if currRanges.To.First != -2 {
-		onItem(mkEmptyToRight(currRanges.To.First, currRanges.To.Last, currRanges.IsGap))
+		onItem(mkEmptyToRight(currRanges.To.First, currRanges.To.Last, currRanges.IsGap, currRanges.Meta))
 	}
+	return nil
 }
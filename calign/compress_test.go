@@ -122,3 +122,27 @@ func TestCompressFromFile(t *testing.T) {
 	assert.Equal(t, 8, len(ans))
 
 }
+
+func TestCompressFromChanCombinesMeta(t *testing.T) {
+	ch := make(chan []mapping.Mapping, 1)
+	items := []mapping.Mapping{
+		mapping.NewMapping(0, 0, 0, 0),
+		mapping.NewMapping(1, 1, -1, -1),
+		mapping.NewMapping(2, 2, -1, -1),
+		mapping.NewMapping(3, 3, 1, 1),
+	}
+	items[0].Meta = mapping.LinkMeta{Status: mapping.StatusManual}
+	items[1].Meta = mapping.LinkMeta{Status: mapping.StatusManual, Score: 0.8, HasScore: true}
+	items[2].Meta = mapping.LinkMeta{Status: mapping.StatusAuto, Score: 0.4, HasScore: true}
+	ch <- items
+	close(ch)
+	ans := make([]mapping.Mapping, 0, 3)
+	CompressFromChan(ch, false, func(item mapping.Mapping) {
+		ans = append(ans, item)
+	})
+	assert.Equal(t, 3, len(ans))
+	assert.Equal(t, items[0], ans[0])
+	assert.Equal(t, mapping.PosRange{First: 1, Last: 2}, ans[1].From)
+	assert.Equal(t, mapping.LinkMeta{Status: mapping.StatusAuto, Score: 0.4, HasScore: true}, ans[1].Meta)
+	assert.Equal(t, items[3], ans[2])
+}
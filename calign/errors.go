@@ -53,3 +53,42 @@ func (err FileImportError) Error() string {
 func NewFileImportError(err error, line int) FileImportError {
 	return FileImportError{message: err.Error(), line: line}
 }
+
+// -------------------------
+
+// NoAlignmentError is returned in case a source file contains
+// a significant number of non-empty lines but none of them
+// is recognized as an alignment. This typically means that
+// the file has a different format than expected.
+type NoAlignmentError struct {
+	fileName    string
+	numNonEmpty int
+}
+
+func (err NoAlignmentError) Error() string {
+	return fmt.Sprintf(
+		"no alignment found in %s (%d non-empty lines read); please make sure the file contains <link xtargets=...> elements, one per line (or try a different input format)",
+		err.fileName, err.numNonEmpty)
+}
+
+// NewNoAlignmentError is the default factory function for NoAlignmentError
+func NewNoAlignmentError(fileName string, numNonEmpty int) NoAlignmentError {
+	return NoAlignmentError{fileName: fileName, numNonEmpty: numNonEmpty}
+}
+
+// -------------------------
+
+// UnknownFormatError is returned in case an unsupported
+// input format is requested
+type UnknownFormatError struct {
+	format string
+}
+
+func (err UnknownFormatError) Error() string {
+	return fmt.Sprintf("Unknown input format '%s'", err.format)
+}
+
+// NewUnknownFormatError is the default factory function for UnknownFormatError
+func NewUnknownFormatError(format string) UnknownFormatError {
+	return UnknownFormatError{format: format}
+}
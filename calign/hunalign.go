@@ -0,0 +1,166 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+const (
+	// hunalignSentenceSep separates multiple sentences
+	// of a single side of a bead in hunalign's bisentence output
+	hunalignSentenceSep = " ~~~ "
+)
+
+// HunalignProcessor reads sentence alignments produced by hunalign.
+// Two formats are supported:
+//
+// * ladder (the default hunalign output) where each line contains
+// a rung 'i<TAB>j<TAB>score' with i, j being sentence indices
+// (a bead is formed by two consecutive rungs),
+//
+// * bisentence (hunalign -text) where each line contains
+// 'sentences1<TAB>sentences2<TAB>score'; here sentence indices
+// are derived from numbers of sentences on each side.
+//
+// Sentence indices are transformed into structure positions
+// (see sentenceIndex).
+type HunalignProcessor struct {
+	sentences  *sentenceIndex
+	bisentence bool
+}
+
+// NewHunalignProcessor creates a new instance of HunalignProcessor.
+// Any of the ids1, ids2 may be nil.
+func NewHunalignProcessor(attr1 AttribMapper, attr2 AttribMapper, ids1 []string, ids2 []string, bisentence bool) *HunalignProcessor {
+	return &HunalignProcessor{
+		sentences:  newSentenceIndex(attr1, attr2, ids1, ids2),
+		bisentence: bisentence,
+	}
+}
+
+// parseScore parses hunalign's score column (which is optional).
+// The second returned value is false in case there is no score.
+func parseScore(items []string, idx int) (float64, bool, error) {
+	if len(items) <= idx {
+		return 0, false, nil
+	}
+	score, err := strconv.ParseFloat(strings.TrimSpace(items[idx]), 64)
+	if err != nil {
+		return 0, false, err
+	}
+	return score, true, nil
+}
+
+// numSentences returns number of sentences in a bisentence column
+func numSentences(col string) int {
+	if col == "" {
+		return 0
+	}
+	return strings.Count(col, hunalignSentenceSep) + 1
+}
+
+// Process reads hunalign output from a provided reader and calls onItem
+// for each bead found. Beads with sentences not found in corpus are
+// logged and skipped, malformed lines end the processing with an error.
+// The sourceName argument is used just for logging.
+func (hp *HunalignProcessor) Process(src io.Reader, sourceName string, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	reader := bufio.NewScanner(src)
+	reader.Buffer(make([]byte, bufio.MaxScanTokenSize), bufferSize)
+	count := 0
+	pos1, pos2 := 0, 0
+	var prevScore float64
+	var prevHasScore bool
+	var i int
+	for i = 0; reader.Scan(); i++ {
+		line := reader.Text()
+		if !hp.bisentence {
+			line = strings.TrimSpace(line)
+		}
+		if line == "" {
+			continue
+		}
+		items := strings.Split(line, "\t")
+		var beg1, end1, beg2, end2 int
+		var score float64
+		var hasScore bool
+		var err error
+		if hp.bisentence {
+			if len(items) < 2 {
+				return NewFileImportError(fmt.Errorf("invalid bisentence line"), i+1)
+			}
+			score, hasScore, err = parseScore(items, 2)
+			if err != nil {
+				return NewFileImportError(err, i+1)
+			}
+			beg1, end1 = pos1, pos1+numSentences(items[0])
+			beg2, end2 = pos2, pos2+numSentences(items[1])
+
+		} else {
+			if len(items) < 2 {
+				return NewFileImportError(fmt.Errorf("invalid ladder rung"), i+1)
+			}
+			r1, err1 := strconv.Atoi(items[0])
+			r2, err2 := strconv.Atoi(items[1])
+			if err1 != nil || err2 != nil {
+				return NewFileImportError(fmt.Errorf("invalid ladder rung [ %s ]", line), i+1)
+			}
+			if r1 < pos1 || r2 < pos2 {
+				return NewFileImportError(fmt.Errorf("ladder rung [ %d, %d ] goes backwards", r1, r2), i+1)
+			}
+			score, hasScore, err = parseScore(items, 2)
+			if err != nil {
+				return NewFileImportError(err, i+1)
+			}
+			// a rung closes the bead started by the previous one
+			// (and its score belongs to the bead it starts)
+			beg1, end1 = pos1, r1
+			beg2, end2 = pos2, r2
+			score, prevScore = prevScore, score
+			hasScore, prevHasScore = prevHasScore, hasScore
+		}
+		pos1, pos2 = end1, end2
+		if beg1 == end1 && beg2 == end2 {
+			continue
+		}
+		mp, err := hp.sentences.createMapping(beg1, end1, beg2, end2, score, hasScore)
+		if err != nil {
+			log.Printf("ERROR: %s on line %d - skipping (file: %s)", err, i+1, sourceName)
+			continue
+		}
+		onItem(mp, count)
+		count++
+	}
+	if err := reader.Err(); err != nil {
+		return NewFileImportError(err, i)
+	}
+	return nil
+}
+
+// ProcessFile reads hunalign output from a file (see Process())
+func (hp *HunalignProcessor) ProcessFile(file *os.File, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	return hp.Process(file, filepath.Base(file.Name()), bufferSize, onItem)
+}
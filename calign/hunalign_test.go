@@ -0,0 +1,113 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func withScore(m mapping.Mapping, score float64) mapping.Mapping {
+	m.Meta = mapping.LinkMeta{Status: mapping.StatusAuto, Score: score, HasScore: true}
+	return m
+}
+
+func withoutScore(m mapping.Mapping) mapping.Mapping {
+	m.Meta = mapping.LinkMeta{Status: mapping.StatusAuto}
+	return m
+}
+
+func processHunalign(hp *HunalignProcessor, src string) ([]mapping.Mapping, error) {
+	ans := make([]mapping.Mapping, 0, 10)
+	err := hp.Process(strings.NewReader(src), "test", 1000, func(item mapping.Mapping, i int) {
+		ans = append(ans, item)
+	})
+	return ans, err
+}
+
+func TestHunalignLadder(t *testing.T) {
+	hp := NewHunalignProcessor(&MockAttr1{}, &MockAttr2{}, nil, nil, false)
+	ans, err := processHunalign(hp, "0\t0\t0.5\n1\t1\t0.3\n3\t2\t-0.1\n3\t3\t0.2\n4\t3\t1\n6\t5\t0\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{
+		withScore(mapping.NewMapping(0, 0, 0, 0), 0.5),
+		withScore(mapping.NewMapping(1, 2, 1, 1), 0.3),
+		withScore(mapping.NewMapping(-1, -1, 2, 2), -0.1),
+		withScore(mapping.NewMapping(3, 3, -1, -1), 0.2),
+		withScore(mapping.NewMapping(4, 5, 3, 4), 1),
+	}, ans)
+}
+
+func TestHunalignLadderWithIDs(t *testing.T) {
+	hp := NewHunalignProcessor(
+		&MockAttr1{},
+		&MockAttr2{},
+		[]string{"foo:3", "foo:4", "foo:5"},
+		[]string{"bar:0", "bar:1"},
+		false,
+	)
+	ans, err := processHunalign(hp, "0\t0\t0.1\n2\t1\t0.2\n3\t2\t0.3\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{
+		withScore(mapping.NewMapping(3, 4, 0, 0), 0.1),
+		withScore(mapping.NewMapping(5, 5, 1, 1), 0.2),
+	}, ans)
+}
+
+func TestHunalignLadderSkipsUnknownIDs(t *testing.T) {
+	hp := NewHunalignProcessor(&MockAttr1{}, &MockAttr2{}, []string{"foo:0", "foo:x"}, nil, false)
+	ans, err := processHunalign(hp, "0\t0\t0.1\n1\t1\t0.2\n2\t2\t0.3\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{withScore(mapping.NewMapping(0, 0, 0, 0), 0.1)}, ans)
+}
+
+func TestHunalignLadderWithoutScores(t *testing.T) {
+	hp := NewHunalignProcessor(&MockAttr1{}, &MockAttr2{}, nil, nil, false)
+	ans, err := processHunalign(hp, "0\t0\n1\t1\t0.3\n2\t3\n3\t3\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{
+		withoutScore(mapping.NewMapping(0, 0, 0, 0)),
+		withScore(mapping.NewMapping(1, 1, 1, 2), 0.3),
+		withoutScore(mapping.NewMapping(2, 2, -1, -1)),
+	}, ans)
+}
+
+func TestHunalignLadderInvalid(t *testing.T) {
+	hp := NewHunalignProcessor(&MockAttr1{}, &MockAttr2{}, nil, nil, false)
+	_, err := processHunalign(hp, "0\t0\t0.1\nfoo\t1\t0.2\n")
+	assert.Error(t, err)
+	_, err = processHunalign(hp, "2\t2\t0.1\n1\t3\t0.2\n")
+	assert.Error(t, err)
+}
+
+func TestHunalignBisentence(t *testing.T) {
+	hp := NewHunalignProcessor(&MockAttr1{}, &MockAttr2{}, nil, nil, true)
+	ans, err := processHunalign(hp, "Hello.\tAhoj.\t0.5\nHow are you? ~~~ Fine.\tJak se máš?\t0.25\n\tDobře.\t-0.3\nThanks.\t\t0\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{
+		withScore(mapping.NewMapping(0, 0, 0, 0), 0.5),
+		withScore(mapping.NewMapping(1, 2, 1, 1), 0.25),
+		withScore(mapping.NewMapping(-1, -1, 2, 2), -0.3),
+		withScore(mapping.NewMapping(3, 3, -1, -1), 0),
+	}, ans)
+	ans, err = processHunalign(hp, "Hello.\tAhoj.\n")
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{withoutScore(mapping.NewMapping(0, 0, 0, 0))}, ans)
+}
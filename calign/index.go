@@ -0,0 +1,76 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// IndexProcessor reads a generic index based sentence alignment
+// (e.g. converted from an output of a sentence aligner without its own
+// reader). Each line has the form 'src<TAB>trg[<TAB>score]' where
+// src and trg are comma separated lists of sentence indices (any of them
+// may be empty) and the optional score is a confidence of the alignment.
+// Sentence indices are transformed into structure positions
+// (see sentenceIndex).
+type IndexProcessor struct {
+	sentences *sentenceIndex
+}
+
+// NewIndexProcessor creates a new instance of IndexProcessor.
+// Any of the ids1, ids2 may be nil.
+func NewIndexProcessor(attr1 AttribMapper, attr2 AttribMapper, ids1 []string, ids2 []string) *IndexProcessor {
+	return &IndexProcessor{
+		sentences: newSentenceIndex(attr1, attr2, ids1, ids2),
+	}
+}
+
+func (bp *IndexProcessor) parseLine(line string) (indexBead, error) {
+	items := strings.Split(line, "\t")
+	if len(items) < 2 || len(items) > 3 {
+		return indexBead{}, fmt.Errorf("invalid index alignment line [ %s ]", line)
+	}
+	var ans indexBead
+	var err error
+	ans.beg1, ans.end1, err = parseIndexList(items[0], ",")
+	if err != nil {
+		return indexBead{}, err
+	}
+	ans.beg2, ans.end2, err = parseIndexList(items[1], ",")
+	if err != nil {
+		return indexBead{}, err
+	}
+	ans.score, ans.hasScore, err = parseScore(items, 2)
+	return ans, err
+}
+
+// Process reads an index based alignment from a provided reader and calls onItem
+// for each alignment found. The sourceName argument is used just for logging.
+func (bp *IndexProcessor) Process(src io.Reader, sourceName string, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	return processIndexBeads(src, sourceName, bufferSize, bp.sentences, bp.parseLine, onItem)
+}
+
+// ProcessFile reads an index based alignment from a file (see Process())
+func (bp *IndexProcessor) ProcessFile(file *os.File, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	return bp.Process(file, filepath.Base(file.Name()), bufferSize, onItem)
+}
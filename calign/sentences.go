@@ -0,0 +1,129 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// LoadIDList loads a list of structure IDs (one per line, in the order
+// of sentences as passed to an aligner). Empty lines are ignored.
+func LoadIDList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	ans := make([]string, 0, 1000)
+	reader := bufio.NewScanner(file)
+	for reader.Scan() {
+		line := strings.TrimSpace(reader.Text())
+		if line != "" {
+			ans = append(ans, line)
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+// sentenceIndex transforms sentence indices used by sentence
+// aligners (hunalign, vecalign, the generic index format) into structure positions.
+// This is done either via ordered lists of structure IDs (ids1, ids2)
+// or, in case a list is not provided, the index is considered to be
+// directly the position of the structure in corpus.
+type sentenceIndex struct {
+	attr1 AttribMapper
+	attr2 AttribMapper
+	ids1  []string
+	ids2  []string
+}
+
+func newSentenceIndex(attr1 AttribMapper, attr2 AttribMapper, ids1 []string, ids2 []string) *sentenceIndex {
+	return &sentenceIndex{
+		attr1: attr1,
+		attr2: attr2,
+		ids1:  ids1,
+		ids2:  ids2,
+	}
+}
+
+// sentencePos transforms a sentence index into a structure position
+func (si *sentenceIndex) sentencePos(idx int, ids []string, attr AttribMapper) (int, error) {
+	if ids == nil {
+		if attr.ID2Str(idx) == "" {
+			return -1, fmt.Errorf("sentence %d not found in corpus", idx)
+		}
+		return idx, nil
+	}
+	if idx >= len(ids) {
+		return -1, fmt.Errorf("sentence %d out of range of the ID list (size %d)", idx, len(ids))
+	}
+	pos := attr.Str2ID(ids[idx])
+	if pos == -1 {
+		return -1, fmt.Errorf("sentence %d [ %s ] not found in corpus", idx, ids[idx])
+	}
+	return pos, nil
+}
+
+// sentenceRange transforms a half-open interval [beg, end) of sentence
+// indices into a position range. An empty interval is transformed into
+// the [-1, -1] range.
+func (si *sentenceIndex) sentenceRange(beg, end int, ids []string, attr AttribMapper) (mapping.PosRange, error) {
+	if beg == end {
+		return mapping.NewEmptyPosRange(), nil
+	}
+	first, err := si.sentencePos(beg, ids, attr)
+	if err != nil {
+		return mapping.PosRange{}, err
+	}
+	last, err := si.sentencePos(end-1, ids, attr)
+	if err != nil {
+		return mapping.PosRange{}, err
+	}
+	return mapping.PosRange{First: first, Last: last}, nil
+}
+
+// createMapping creates a mapping from a bead specified by
+// half-open intervals of sentence indices. The score (if any)
+// is attached as an automatic alignment metadata.
+func (si *sentenceIndex) createMapping(beg1, end1, beg2, end2 int, score float64, hasScore bool) (mapping.Mapping, error) {
+	l1, err := si.sentenceRange(beg1, end1, si.ids1, si.attr1)
+	if err != nil {
+		return mapping.Mapping{}, err
+	}
+	l2, err := si.sentenceRange(beg2, end2, si.ids2, si.attr2)
+	if err != nil {
+		return mapping.Mapping{}, err
+	}
+	meta := mapping.LinkMeta{Status: mapping.StatusAuto}
+	if hasScore {
+		meta.Score = score
+		meta.HasScore = true
+	}
+	return mapping.Mapping{
+		From: l1,
+		To:   l2,
+		Meta: meta,
+	}, nil
+}
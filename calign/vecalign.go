@@ -0,0 +1,173 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// indexBead is a single alignment bead specified by
+// half-open intervals of sentence indices
+type indexBead struct {
+	beg1, end1 int
+	beg2, end2 int
+	score      float64
+	hasScore   bool
+}
+
+// parseIndexList parses a list of sentence indices separated by sep
+// (e.g. "3, 4") and transforms it into a half-open interval.
+// The indices must be consecutive. An empty list produces an empty
+// interval.
+func parseIndexList(src string, sep string) (int, int, error) {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return 0, 0, nil
+	}
+	items := strings.Split(src, sep)
+	beg := -1
+	for i, item := range items {
+		v, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid sentence index [ %s ]", item)
+		}
+		if i == 0 {
+			beg = v
+
+		} else if v != beg+i {
+			return 0, 0, fmt.Errorf("sentence indices [ %s ] are not consecutive", src)
+		}
+	}
+	return beg, beg + len(items), nil
+}
+
+// processIndexBeads reads src line by line, parses each non-empty line
+// using parseLine and calls onItem for each resulting mapping. Beads with
+// sentences not found in corpus are logged and skipped, malformed lines end
+// the processing with an error.
+func processIndexBeads(
+	src io.Reader,
+	sourceName string,
+	bufferSize int,
+	sentences *sentenceIndex,
+	parseLine func(line string) (indexBead, error),
+	onItem func(item mapping.Mapping, i int),
+) error {
+	reader := bufio.NewScanner(src)
+	reader.Buffer(make([]byte, bufio.MaxScanTokenSize), bufferSize)
+	count := 0
+	var i int
+	for i = 0; reader.Scan(); i++ {
+		line := strings.TrimRight(reader.Text(), " \r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		bead, err := parseLine(line)
+		if err != nil {
+			return NewFileImportError(err, i+1)
+		}
+		if bead.beg1 == bead.end1 && bead.beg2 == bead.end2 {
+			continue
+		}
+		mp, err := sentences.createMapping(bead.beg1, bead.end1, bead.beg2, bead.end2, bead.score, bead.hasScore)
+		if err != nil {
+			log.Printf("ERROR: %s on line %d - skipping (file: %s)", err, i+1, sourceName)
+			continue
+		}
+		onItem(mp, count)
+		count++
+	}
+	if err := reader.Err(); err != nil {
+		return NewFileImportError(err, i)
+	}
+	return nil
+}
+
+// VecalignProcessor reads sentence alignments produced by vecalign.
+// Each line has the form '[0, 1]:[2]:0.43' where the first two items are
+// lists of sentence indices and the last one is an alignment cost
+// (lower is better; see costToScore). Sentence indices are transformed
+// into structure positions (see sentenceIndex).
+type VecalignProcessor struct {
+	sentences *sentenceIndex
+}
+
+// NewVecalignProcessor creates a new instance of VecalignProcessor.
+// Any of the ids1, ids2 may be nil.
+func NewVecalignProcessor(attr1 AttribMapper, attr2 AttribMapper, ids1 []string, ids2 []string) *VecalignProcessor {
+	return &VecalignProcessor{
+		sentences: newSentenceIndex(attr1, attr2, ids1, ids2),
+	}
+}
+
+func (vp *VecalignProcessor) parseLine(line string) (indexBead, error) {
+	items := strings.Split(line, ":")
+	if len(items) < 2 || len(items) > 3 {
+		return indexBead{}, fmt.Errorf("invalid vecalign line [ %s ]", line)
+	}
+	var ans indexBead
+	var err error
+	for i, item := range items[:2] {
+		item = strings.TrimSpace(item)
+		if !strings.HasPrefix(item, "[") || !strings.HasSuffix(item, "]") {
+			return indexBead{}, fmt.Errorf("invalid vecalign index list [ %s ]", item)
+		}
+		if i == 0 {
+			ans.beg1, ans.end1, err = parseIndexList(item[1:len(item)-1], ",")
+
+		} else {
+			ans.beg2, ans.end2, err = parseIndexList(item[1:len(item)-1], ",")
+		}
+		if err != nil {
+			return indexBead{}, err
+		}
+	}
+	var cost float64
+	cost, ans.hasScore, err = parseScore(items, 2)
+	ans.score = costToScore(cost)
+	return ans, err
+}
+
+// costToScore transforms a vecalign alignment cost (where lower
+// is better) into a link score (where higher is better). Zero cost
+// produces score 1, the score approaches zero as the cost grows.
+func costToScore(cost float64) float64 {
+	if cost < 0 {
+		cost = 0
+	}
+	return 1 / (1 + cost)
+}
+
+// Process reads vecalign output from a provided reader and calls onItem
+// for each alignment found. The sourceName argument is used just for logging.
+func (vp *VecalignProcessor) Process(src io.Reader, sourceName string, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	return processIndexBeads(src, sourceName, bufferSize, vp.sentences, vp.parseLine, onItem)
+}
+
+// ProcessFile reads vecalign output from a file (see Process())
+func (vp *VecalignProcessor) ProcessFile(file *os.File, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	return vp.Process(file, filepath.Base(file.Name()), bufferSize, onItem)
+}
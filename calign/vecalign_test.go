@@ -0,0 +1,77 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseIndexList(t *testing.T) {
+	beg, end, err := parseIndexList("3, 4,5", ",")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, beg)
+	assert.Equal(t, 6, end)
+	beg, end, err = parseIndexList(" ", ",")
+	assert.Nil(t, err)
+	assert.Equal(t, beg, end)
+	_, _, err = parseIndexList("3, 5", ",")
+	assert.Error(t, err)
+	_, _, err = parseIndexList("3, x", ",")
+	assert.Error(t, err)
+}
+
+func TestVecalignProcess(t *testing.T) {
+	vp := NewVecalignProcessor(&MockAttr1{}, &MockAttr2{}, nil, []string{"bar:2", "bar:3", "bar:4"})
+	ans := make([]mapping.Mapping, 0, 5)
+	src := "[0]:[0]:0.25\n[1, 2]:[1]:1\n[3]:[]:0.0\n[]:[2]\n"
+	err := vp.Process(strings.NewReader(src), "test", 1000, func(item mapping.Mapping, i int) {
+		ans = append(ans, item)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{
+		withScore(mapping.NewMapping(0, 0, 2, 2), 0.8),
+		withScore(mapping.NewMapping(1, 2, 3, 3), 0.5),
+		withScore(mapping.NewMapping(3, 3, -1, -1), 1),
+		withoutScore(mapping.NewMapping(-1, -1, 4, 4)),
+	}, ans)
+}
+
+func TestVecalignInvalidLine(t *testing.T) {
+	vp := NewVecalignProcessor(&MockAttr1{}, &MockAttr2{}, nil, nil)
+	err := vp.Process(strings.NewReader("[0]:[0]:0.1\n0:[1]:0.2\n"), "test", 1000, func(item mapping.Mapping, i int) {})
+	assert.Error(t, err)
+}
+
+func TestIndexProcess(t *testing.T) {
+	bp := NewIndexProcessor(&MockAttr1{}, &MockAttr2{}, nil, nil)
+	ans := make([]mapping.Mapping, 0, 5)
+	src := "0\t0\t0.8\n1,2\t1\n\t2\t0.1\n3\t\t0.2\n"
+	err := bp.Process(strings.NewReader(src), "test", 1000, func(item mapping.Mapping, i int) {
+		ans = append(ans, item)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{
+		withScore(mapping.NewMapping(0, 0, 0, 0), 0.8),
+		withoutScore(mapping.NewMapping(1, 2, 1, 1)),
+		withScore(mapping.NewMapping(-1, -1, 2, 2), 0.1),
+		withScore(mapping.NewMapping(3, 3, -1, -1), 0.2),
+	}, ans)
+}
@@ -0,0 +1,137 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// lineCountingReader is a reader tracking number of
+// newline characters read so far. As it implements io.ByteReader,
+// xml.Decoder reads data byte by byte from it (i.e. no additional
+// buffering is involved and the line number is quite accurate).
+type lineCountingReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (lr *lineCountingReader) ReadByte() (byte, error) {
+	b, err := lr.reader.ReadByte()
+	if b == '\n' && err == nil {
+		lr.line++
+	}
+	return b, err
+}
+
+func (lr *lineCountingReader) Read(p []byte) (int, error) {
+	var i int
+	for i = 0; i < len(p); i++ {
+		b, err := lr.ReadByte()
+		if err != nil {
+			return i, err
+		}
+		p[i] = b
+	}
+	return i, nil
+}
+
+// XMLProcessor is an alternative to Processor using a real (streaming)
+// XML tokenizer to read XCES/cesAlign documents. Compared with Processor,
+// it is slower but it does not depend on how the source is formatted
+// (multi-line <link> elements, quote style, escaped entities, multiple
+// elements per line).
+type XMLProcessor struct {
+	proc *Processor
+}
+
+// NewXMLProcessor creates a new instance of XMLProcessor
+func NewXMLProcessor(attr1 AttribMapper, attr2 AttribMapper) *XMLProcessor {
+	return &XMLProcessor{
+		proc: &Processor{
+			attr1: attr1,
+			attr2: attr2,
+		},
+	}
+}
+
+func getXMLAttr(elm *xml.StartElement, name string) (string, bool) {
+	for _, attr := range elm.Attr {
+		if attr.Name.Local == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// Process reads an XCES alignment document from a provided reader
+// and calls onItem for each alignment found in <link> elements.
+// The sourceName argument is used just for logging. The bufferSize
+// argument is ignored as the XML reader does not limit line length.
+func (xp *XMLProcessor) Process(src io.Reader, sourceName string, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	reader := &lineCountingReader{reader: bufio.NewReader(src)}
+	decoder := xml.NewDecoder(reader)
+	decoder.Strict = false
+	var linkGrp string
+	count := 0
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+
+		} else if err != nil {
+			return NewFileImportError(err, reader.line+1)
+		}
+		elm, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		switch elm.Name.Local {
+		case "linkGrp":
+			fromDoc, _ := getXMLAttr(&elm, "fromDoc")
+			toDoc, _ := getXMLAttr(&elm, "toDoc")
+			linkGrp = fromDoc + " -> " + toDoc
+		case "link":
+			xtargets, ok := getXMLAttr(&elm, "xtargets")
+			if !ok {
+				log.Printf("ERROR: <link> without 'xtargets' on line %d (file: %s, linkGrp: %s)",
+					reader.line+1, sourceName, linkGrp)
+				continue
+			}
+			mp, err := xp.proc.processXTargets(xtargets, reader.line)
+			if err != nil {
+				log.Printf("ERROR: %s (file: %s, linkGrp: %s)", err, sourceName, linkGrp)
+				continue
+			}
+			mp.Meta.Status, _ = getXMLAttr(&elm, "status")
+			onItem(mp, count)
+			count++
+		}
+	}
+	return nil
+}
+
+// ProcessFile reads an input XML file (see Process())
+func (xp *XMLProcessor) ProcessFile(file *os.File, bufferSize int, onItem func(item mapping.Mapping, i int)) error {
+	return xp.Process(file, filepath.Base(file.Name()), bufferSize, onItem)
+}
@@ -0,0 +1,75 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package calign
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXMLProcessFile(t *testing.T) {
+	valData := []mapping.Mapping{
+		withStatus(mapping.NewMapping(0, 0, 0, 0), mapping.StatusManual),
+		withStatus(mapping.NewMapping(1, 1, 1, 1), mapping.StatusAuto),
+		withStatus(mapping.NewMapping(2, 3, 2, 2), mapping.StatusManual),
+		withStatus(mapping.NewMapping(-1, -1, 3, 3), mapping.StatusManual),
+		withStatus(mapping.NewMapping(4, 4, 4, 5), mapping.StatusManual),
+		withStatus(mapping.NewMapping(5, 5, -1, -1), mapping.StatusManual),
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	f, err := os.Open(filepath.Join(cwd, "..", "testdata", "foo-ids.ces.xml"))
+	if err != nil {
+		panic(err)
+	}
+	p := NewXMLProcessor(&MockAttr1{}, &MockAttr2{})
+	ans := make([]mapping.Mapping, 0, len(valData))
+	err = p.ProcessFile(f, 1000, func(item mapping.Mapping, i int) {
+		ans = append(ans, item)
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, valData, ans)
+}
+
+func TestXMLProcessInvalidXML(t *testing.T) {
+	src := "<linkGrp>\n<link xtargets='foo:0;bar:0' />\n<link xtargets='foo:1;bar:1 />\n</linkGrp>"
+	p := NewXMLProcessor(&MockAttr1{}, &MockAttr2{})
+	i := 0
+	err := p.Process(strings.NewReader(src), "test", 1000, func(item mapping.Mapping, _ int) {
+		i++
+	})
+	assert.IsType(t, FileImportError{}, err)
+	assert.Equal(t, 1, i)
+}
+
+func TestLineCountingReader(t *testing.T) {
+	p := &lineCountingReader{reader: bufio.NewReader(strings.NewReader("a\nb\n\nc"))}
+	buf := make([]byte, 5)
+	n, err := p.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, n)
+	assert.Equal(t, 3, p.line)
+}
@@ -0,0 +1,368 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package diff provides functions to compare two versions
+// of an alignment between the same pair of corpora.
+package diff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/export"
+	"github.com/czcorpus/ictools/mapping"
+)
+
+const (
+	// HunkAdded marks a hunk containing only added links
+	HunkAdded = "added"
+
+	// HunkRemoved marks a hunk containing only removed links
+	HunkRemoved = "removed"
+
+	// HunkChanged marks a hunk containing both removed and added links
+	HunkChanged = "changed"
+)
+
+// linkKey identifies a link regardless of its metadata
+type linkKey struct {
+	from mapping.PosRange
+	to   mapping.PosRange
+}
+
+func keyOf(item mapping.Mapping) linkKey {
+	return linkKey{from: item.From, to: item.To}
+}
+
+// Hunk is a continuous sequence of changed links
+// (i.e. there is no unchanged link between them)
+type Hunk struct {
+	Doc     string
+	Removed []mapping.Mapping
+	Added   []mapping.Mapping
+}
+
+// Type returns one of HunkAdded, HunkRemoved, HunkChanged
+func (h *Hunk) Type() string {
+	if len(h.Removed) == 0 {
+		return HunkAdded
+	}
+	if len(h.Added) == 0 {
+		return HunkRemoved
+	}
+	return HunkChanged
+}
+
+// span returns the lowest and the highest position
+// of a side covered by the hunk (-1, -1 if none)
+func (h *Hunk) span(first bool) (int, int) {
+	lft, rgt := -1, -1
+	for _, items := range [][]mapping.Mapping{h.Removed, h.Added} {
+		for _, item := range items {
+			rng := item.To
+			if first {
+				rng = item.From
+			}
+			if rng.First == -1 {
+				continue
+			}
+			if lft == -1 || rng.First < lft {
+				lft = rng.First
+			}
+			if rng.Last > rgt {
+				rgt = rng.Last
+			}
+		}
+	}
+	return lft, rgt
+}
+
+func (h *Hunk) describeSpan(first bool) string {
+	lft, rgt := h.span(first)
+	if lft == -1 {
+		return "-"
+	}
+	return fmt.Sprintf("%d..%d", lft, rgt)
+}
+
+// ----------------------------------------------
+
+// DocSummary contains numbers of changes within a document
+type DocSummary struct {
+	Doc        string
+	NumHunks   int
+	NumAdded   int
+	NumRemoved int
+}
+
+// Summary contains numbers of changes between compared alignments
+type Summary struct {
+	NumAddedHunks   int
+	NumRemovedHunks int
+	NumChangedHunks int
+	NumAdded        int
+	NumRemoved      int
+	NumUnchanged    int
+
+	// Documents contains per-document numbers (available only
+	// in case documents are identified)
+	Documents []*DocSummary
+}
+
+// NumHunks returns a total number of hunks
+func (s *Summary) NumHunks() int {
+	return s.NumAddedHunks + s.NumRemovedHunks + s.NumChangedHunks
+}
+
+// WriteText writes the summary in a human readable form
+// (as lines starting with the '#' character)
+func (s *Summary) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("# hunks: %d (%d added, %d removed, %d changed)\n", s.NumHunks(), s.NumAddedHunks,
+		s.NumRemovedHunks, s.NumChangedHunks)
+	printf("# links: %d added, %d removed, %d unchanged\n", s.NumAdded, s.NumRemoved, s.NumUnchanged)
+	for _, doc := range s.Documents {
+		printf("# %s: %d hunks, %d links added, %d links removed\n", doc.Doc, doc.NumHunks,
+			doc.NumAdded, doc.NumRemoved)
+	}
+	return err
+}
+
+// ----------------------------------------------
+
+// Diff compares two versions (old, new) of an alignment. Items
+// of both versions are expected to be added in the order of their
+// source data. Ranges of unaligned structures are split into
+// individual 1-0 (0-1) links so differently compressed (but otherwise
+// equal) alignments have no differences. Link metadata (gap flags,
+// statuses) are ignored.
+type Diff struct {
+	attr1  attrib.PosAttr
+	attr2  attrib.PosAttr
+	filter export.GroupFilter
+	old    []mapping.Mapping
+	new    []mapping.Mapping
+}
+
+func appendItem(items []mapping.Mapping, item mapping.Mapping) []mapping.Mapping {
+	if item.IsError() || item.IsEmpty() {
+		return items
+	}
+	if item.From.First == -1 {
+		for i := item.To.First; i <= item.To.Last; i++ {
+			items = append(items, mapping.NewMapping(-1, -1, i, i))
+		}
+
+	} else if item.To.First == -1 {
+		for i := item.From.First; i <= item.From.Last; i++ {
+			items = append(items, mapping.NewMapping(i, i, -1, -1))
+		}
+
+	} else {
+		items = append(items, mapping.NewMapping(item.From.First, item.From.Last, item.To.First, item.To.Last))
+	}
+	return items
+}
+
+// AddOld adds an item of the old alignment version
+func (d *Diff) AddOld(item mapping.Mapping) {
+	d.old = appendItem(d.old, item)
+}
+
+// AddNew adds an item of the new alignment version
+func (d *Diff) AddNew(item mapping.Mapping) {
+	d.new = appendItem(d.new, item)
+}
+
+func (d *Diff) docOf(item mapping.Mapping) string {
+	if item.From.First != -1 {
+		return d.filter.ExtractGroupID(d.attr1.ID2Str(item.From.First))
+	}
+	return d.filter.ExtractGroupID(d.attr2.ID2Str(item.To.First))
+}
+
+// splitByDocs splits a hunk into hunks belonging to individual
+// documents (in the order of their first appearance). In case documents
+// cannot be identified, the original hunk is returned.
+func (d *Diff) splitByDocs(hunk *Hunk) []*Hunk {
+	if d.filter == nil || d.attr1 == nil || d.attr2 == nil {
+		return []*Hunk{hunk}
+	}
+	ans := make([]*Hunk, 0, 1)
+	docHunks := make(map[string]*Hunk)
+	getHunk := func(item mapping.Mapping) *Hunk {
+		doc := d.docOf(item)
+		h, ok := docHunks[doc]
+		if !ok {
+			h = &Hunk{Doc: doc}
+			docHunks[doc] = h
+			ans = append(ans, h)
+		}
+		return h
+	}
+	for _, item := range hunk.Removed {
+		h := getHunk(item)
+		h.Removed = append(h.Removed, item)
+	}
+	for _, item := range hunk.Added {
+		h := getHunk(item)
+		h.Added = append(h.Added, item)
+	}
+	return ans
+}
+
+// Hunks compares both alignment versions and returns found hunks
+// along with a number of unchanged links. Links are considered
+// unchanged if they are found in both versions (regardless of their
+// relative order). In case documents can be identified, hunks never
+// span more than one document.
+func (d *Diff) Hunks() ([]*Hunk, int) {
+	oldKeys := make(map[linkKey]bool)
+	for _, item := range d.old {
+		oldKeys[keyOf(item)] = true
+	}
+	newKeys := make(map[linkKey]bool)
+	for _, item := range d.new {
+		newKeys[keyOf(item)] = true
+	}
+	ans := make([]*Hunk, 0, 100)
+	numUnchanged := 0
+	i, j := 0, 0
+	for i < len(d.old) || j < len(d.new) {
+		hunk := &Hunk{}
+		for ; i < len(d.old) && !newKeys[keyOf(d.old[i])]; i++ {
+			hunk.Removed = append(hunk.Removed, d.old[i])
+		}
+		for ; j < len(d.new) && !oldKeys[keyOf(d.new[j])]; j++ {
+			hunk.Added = append(hunk.Added, d.new[j])
+		}
+		if len(hunk.Removed) > 0 || len(hunk.Added) > 0 {
+			ans = append(ans, d.splitByDocs(hunk)...)
+			continue
+		}
+		if i < len(d.old) {
+			i++
+		}
+		if j < len(d.new) {
+			j++
+		}
+		numUnchanged++
+	}
+	return ans, numUnchanged
+}
+
+func (d *Diff) describe(item mapping.Mapping) string {
+	if d.attr1 == nil || d.attr2 == nil {
+		return ""
+	}
+	return "\t# " + item.From.Describe(d.attr1) + " -> " + item.To.Describe(d.attr2)
+}
+
+// Write compares both alignment versions and writes found changes
+// in a unified-diff-like form. Each hunk starts with a header containing
+// LANG1 and LANG2 positions affected by the hunk (and a document ID
+// in case documents are identified), followed by removed ('-') and added
+// ('+') links. In case string IDs are available, they are attached
+// to the links.
+func (d *Diff) Write(w io.Writer, oldName, newName string) (*Summary, error) {
+	hunks, numUnchanged := d.Hunks()
+	summary := &Summary{NumUnchanged: numUnchanged}
+	docs := make(map[string]*DocSummary)
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("--- %s\n+++ %s\n", oldName, newName)
+	for _, hunk := range hunks {
+		switch hunk.Type() {
+		case HunkAdded:
+			summary.NumAddedHunks++
+		case HunkRemoved:
+			summary.NumRemovedHunks++
+		case HunkChanged:
+			summary.NumChangedHunks++
+		}
+		summary.NumAdded += len(hunk.Added)
+		summary.NumRemoved += len(hunk.Removed)
+		if hunk.Doc != "" {
+			printf("@@ LANG1 %s LANG2 %s @@ %s\n", hunk.describeSpan(true), hunk.describeSpan(false), hunk.Doc)
+			doc, ok := docs[hunk.Doc]
+			if !ok {
+				doc = &DocSummary{Doc: hunk.Doc}
+				docs[hunk.Doc] = doc
+				summary.Documents = append(summary.Documents, doc)
+			}
+			doc.NumHunks++
+			doc.NumAdded += len(hunk.Added)
+			doc.NumRemoved += len(hunk.Removed)
+
+		} else {
+			printf("@@ LANG1 %s LANG2 %s @@\n", hunk.describeSpan(true), hunk.describeSpan(false))
+		}
+		for _, item := range hunk.Removed {
+			printf("-%s\t%s%s\n", item.From, item.To, d.describe(item))
+		}
+		for _, item := range hunk.Added {
+			printf("+%s\t%s%s\n", item.From, item.To, d.describe(item))
+		}
+	}
+	return summary, err
+}
+
+// ReadNumeric reads all the items of a numeric alignment (text or binary)
+// and passes them to onItem. Invalid data and error records are reported
+// as errors.
+func ReadNumeric(src io.Reader, onItem func(item mapping.Mapping)) (mapping.Header, error) {
+	reader, err := mapping.NewReader(src)
+	if err != nil {
+		return mapping.Header{}, err
+	}
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			return reader.Header(), nil
+
+		} else if err != nil {
+			return reader.Header(), err
+		}
+		if item.IsError() {
+			return reader.Header(), fmt.Errorf("the '%s' mark found on line %d", mapping.ErrorMark, reader.Line())
+		}
+		onItem(item)
+	}
+}
+
+// NewDiff creates a new Diff instance. Structure string IDs
+// (attr1, attr2) are optional (nil) and used to describe changed links.
+// In case a group filter is provided (along with the IDs), hunks are
+// attributed to documents.
+func NewDiff(attr1, attr2 attrib.PosAttr, filter export.GroupFilter) *Diff {
+	return &Diff{
+		attr1:  attr1,
+		attr2:  attr2,
+		filter: filter,
+		old:    make([]mapping.Mapping, 0, 1000),
+		new:    make([]mapping.Mapping, 0, 1000),
+	}
+}
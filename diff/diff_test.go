@@ -0,0 +1,110 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package diff
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/czcorpus/ictools/export"
+	"github.com/stretchr/testify/assert"
+)
+
+func createDiff(oldData, newData string, attr1, attr2 attrib.PosAttr, filter export.GroupFilter) *Diff {
+	d := NewDiff(attr1, attr2, filter)
+	ReadNumeric(strings.NewReader(oldData), d.AddOld)
+	ReadNumeric(strings.NewReader(newData), d.AddNew)
+	return d
+}
+
+func TestNoDifferences(t *testing.T) {
+	d := createDiff("0\t0\n-1\t1,2\n1\t3\n", "0\t0\n-1\t1\n-1\t2\n1\t3\tg\n", nil, nil, nil)
+	hunks, numUnchanged := d.Hunks()
+	assert.Equal(t, 0, len(hunks))
+	assert.Equal(t, 4, numUnchanged)
+}
+
+func TestHunks(t *testing.T) {
+	d := createDiff(
+		"0\t0\n1,2\t1\n3\t2\n4\t-1\n",
+		"-1\t0\n0\t-1\n1\t1\n2\t-1\n3\t2\n",
+		nil, nil, nil,
+	)
+	hunks, numUnchanged := d.Hunks()
+	assert.Equal(t, 1, numUnchanged)
+	assert.Equal(t, 2, len(hunks))
+	assert.Equal(t, HunkChanged, hunks[0].Type())
+	assert.Equal(t, 2, len(hunks[0].Removed))
+	assert.Equal(t, 4, len(hunks[0].Added))
+	assert.Equal(t, HunkRemoved, hunks[1].Type())
+	assert.Equal(t, "4", hunks[1].Removed[0].From.String())
+}
+
+func TestAddedHunk(t *testing.T) {
+	d := createDiff("0\t0\n", "0\t0\n-1\t1\n", nil, nil, nil)
+	hunks, _ := d.Hunks()
+	assert.Equal(t, 1, len(hunks))
+	assert.Equal(t, HunkAdded, hunks[0].Type())
+}
+
+func TestWrite(t *testing.T) {
+	attr1 := &attribtest.ListAttr{IDs: []string{"cs:doc1:0:1:1", "cs:doc1:0:1:2", "cs:doc2:0:1:1"}}
+	attr2 := &attribtest.ListAttr{IDs: []string{"en:doc1:0:1:1", "en:doc2:0:1:1"}}
+	d := createDiff(
+		"0,1\t0\n2\t1\n",
+		"0\t0\n1\t-1\n2\t-1\n-1\t1\n",
+		attr1, attr2, export.NewGroupFilter(export.ExportTypeIntercorp),
+	)
+	var buff bytes.Buffer
+	summary, err := d.Write(&buff, "old", "new")
+	assert.Nil(t, err)
+	assert.Equal(t, "--- old\n+++ new\n"+
+		"@@ LANG1 0..1 LANG2 0..0 @@ doc1\n"+
+		"-0,1\t0\t# cs:doc1:0:1:1..cs:doc1:0:1:2 -> en:doc1:0:1:1\n"+
+		"+0\t0\t# cs:doc1:0:1:1 -> en:doc1:0:1:1\n"+
+		"+1\t-1\t# cs:doc1:0:1:2 -> -\n"+
+		"@@ LANG1 2..2 LANG2 1..1 @@ doc2\n"+
+		"-2\t1\t# cs:doc2:0:1:1 -> en:doc2:0:1:1\n"+
+		"+2\t-1\t# cs:doc2:0:1:1 -> -\n"+
+		"+-1\t1\t# - -> en:doc2:0:1:1\n", buff.String())
+	assert.Equal(t, 2, summary.NumChangedHunks)
+	assert.Equal(t, 4, summary.NumAdded)
+	assert.Equal(t, 2, summary.NumRemoved)
+	assert.Equal(t, 0, summary.NumUnchanged)
+	assert.Equal(t, 2, len(summary.Documents))
+	assert.Equal(t, "doc2", summary.Documents[1].Doc)
+	assert.Equal(t, 2, summary.Documents[1].NumAdded)
+}
+
+func TestWriteWithoutIDs(t *testing.T) {
+	d := createDiff("0\t0\n", "0\t0\n1\t-1\n", nil, nil, nil)
+	var buff bytes.Buffer
+	summary, err := d.Write(&buff, "old", "new")
+	assert.Nil(t, err)
+	assert.Equal(t, "--- old\n+++ new\n@@ LANG1 1..1 LANG2 - @@\n+1\t-1\n", buff.String())
+	buff.Reset()
+	summary.WriteText(&buff)
+	assert.Equal(t, "# hunks: 1 (1 added, 0 removed, 0 changed)\n# links: 1 added, 0 removed, 1 unchanged\n", buff.String())
+}
+
+func TestErrorRecord(t *testing.T) {
+	_, err := ReadNumeric(strings.NewReader("0\t0\nERROR\n"), NewDiff(nil, nil, nil).AddOld)
+	assert.Error(t, err)
+}
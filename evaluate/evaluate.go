@@ -0,0 +1,317 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package evaluate provides functions to compare a numeric
+// alignment with a gold (manually corrected) one.
+package evaluate
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/mapping"
+)
+
+const (
+	// DisagreementMissing marks a gold link not found in the tested alignment
+	DisagreementMissing = "missing"
+
+	// DisagreementSpurious marks a tested link not found in the gold alignment
+	DisagreementSpurious = "spurious"
+)
+
+// Link is a single alignment link. Unaligned structures
+// are represented by 1-0 (0-1) links with an empty range
+// on the other side.
+type Link struct {
+	From mapping.PosRange
+	To   mapping.PosRange
+}
+
+func (l Link) isNull() bool {
+	return l.From.First == -1 || l.To.First == -1
+}
+
+// pair is a pair of aligned structures
+type pair struct {
+	pos1 int
+	pos2 int
+}
+
+// forEachPair calls fn for each pair of structures the link
+// aligns (i.e. a Cartesian product of both ranges). The iteration
+// stops once fn returns true.
+func (l Link) forEachPair(fn func(p pair) bool) {
+	if l.isNull() {
+		return
+	}
+	for i := l.From.First; i <= l.From.Last; i++ {
+		for j := l.To.First; j <= l.To.Last; j++ {
+			if fn(pair{pos1: i, pos2: j}) {
+				return
+			}
+		}
+	}
+}
+
+// alignment contains links of an alignment along
+// with derived structures used for comparison
+type alignment struct {
+	header mapping.Header
+	links  []Link
+	index  map[Link]bool
+	pairs  map[pair]bool
+}
+
+func (a *alignment) add(link Link) {
+	a.links = append(a.links, link)
+	a.index[link] = true
+	link.forEachPair(func(p pair) bool {
+		a.pairs[p] = true
+		return false
+	})
+}
+
+// overlaps tests whether a link shares at least one pair
+// of structures with some link of the alignment. Null links
+// must match exactly.
+func (a *alignment) overlaps(link Link) bool {
+	if link.isNull() {
+		return a.index[link]
+	}
+	ans := false
+	link.forEachPair(func(p pair) bool {
+		ans = a.pairs[p]
+		return ans
+	})
+	return ans
+}
+
+// readAlignment reads all the links of a numeric alignment (text or binary).
+// Compressed ranges of unaligned structures are split into individual
+// 1-0 (0-1) links.
+func readAlignment(src io.Reader) (*alignment, error) {
+	reader, err := mapping.NewReader(src)
+	if err != nil {
+		return nil, err
+	}
+	ans := &alignment{
+		header: reader.Header(),
+		links:  make([]Link, 0, 1000),
+		index:  make(map[Link]bool),
+		pairs:  make(map[pair]bool),
+	}
+	empty := mapping.NewEmptyPosRange()
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			return ans, nil
+
+		} else if err != nil {
+			return nil, err
+		}
+		if item.IsError() {
+			return nil, fmt.Errorf("the '%s' mark found on line %d", mapping.ErrorMark, reader.Line())
+		}
+		if item.IsEmpty() {
+			continue
+
+		} else if item.From.First == -1 {
+			for i := item.To.First; i <= item.To.Last; i++ {
+				ans.add(Link{From: empty, To: mapping.PosRange{First: i, Last: i}})
+			}
+
+		} else if item.To.First == -1 {
+			for i := item.From.First; i <= item.From.Last; i++ {
+				ans.add(Link{From: mapping.PosRange{First: i, Last: i}, To: empty})
+			}
+
+		} else {
+			ans.add(Link{From: item.From, To: item.To})
+		}
+	}
+}
+
+// ----------------------------------------------
+
+// Scores contains precision, recall and F1 score. As the numbers
+// of correct items may differ between the tested and the gold
+// alignment (lax evaluation), both are provided.
+type Scores struct {
+	NumTest        int
+	NumGold        int
+	NumCorrectTest int
+	NumCorrectGold int
+	Precision      float64
+	Recall         float64
+	F1             float64
+}
+
+func (s *Scores) calculate() {
+	if s.NumTest > 0 {
+		s.Precision = float64(s.NumCorrectTest) / float64(s.NumTest)
+	}
+	if s.NumGold > 0 {
+		s.Recall = float64(s.NumCorrectGold) / float64(s.NumGold)
+	}
+	if s.Precision+s.Recall > 0 {
+		s.F1 = 2 * s.Precision * s.Recall / (s.Precision + s.Recall)
+	}
+}
+
+// Result contains evaluation scores:
+//
+// * LinksStrict - a link is correct only if exactly the same link
+// exists in the other alignment,
+//
+// * LinksLax - a link is correct if it shares at least one pair
+// of structures with some link of the other alignment (1-0 and 0-1
+// links must still match exactly),
+//
+// * Pairs - each n-m link is split into n*m pairs of aligned
+// structures which are compared (1-0 and 0-1 links are ignored).
+type Result struct {
+	LinksStrict Scores
+	LinksLax    Scores
+	Pairs       Scores
+}
+
+// WriteText writes the scores in a human readable form
+func (r *Result) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printf("%-16s %10s %10s %10s %10s %10s\n", "", "test", "gold", "precision", "recall", "F1")
+	for _, row := range []struct {
+		name   string
+		scores Scores
+	}{
+		{name: "links (strict)", scores: r.LinksStrict},
+		{name: "links (lax)", scores: r.LinksLax},
+		{name: "pairs", scores: r.Pairs},
+	} {
+		printf("%-16s %10d %10d %10.4f %10.4f %10.4f\n", row.name, row.scores.NumTest, row.scores.NumGold,
+			row.scores.Precision, row.scores.Recall, row.scores.F1)
+	}
+	return err
+}
+
+// Disagreement describes a link found only in one
+// of the compared alignments
+type Disagreement struct {
+	Type        string
+	Link        Link
+	Description string
+}
+
+func (d Disagreement) String() string {
+	return fmt.Sprintf("%s: [%s -> %s] (%s)", d.Type, d.Link.From, d.Link.To, d.Description)
+}
+
+// ----------------------------------------------
+
+// Evaluator compares a tested alignment with a gold one
+type Evaluator struct {
+	attr1          attrib.PosAttr
+	attr2          attrib.PosAttr
+	onDisagreement func(d Disagreement)
+}
+
+func (e *Evaluator) report(dtype string, link Link) {
+	e.onDisagreement(Disagreement{
+		Type:        dtype,
+		Link:        link,
+		Description: link.From.Describe(e.attr1) + " -> " + link.To.Describe(e.attr2),
+	})
+}
+
+// Run reads a gold and a tested alignment and compares them. In case
+// the data contain headers, they are compared with each other and with
+// the expected one. Links found only in one of the alignments are passed
+// to the onDisagreement function provided to NewEvaluator (missing links
+// first, in the order of the gold data, then spurious ones).
+func (e *Evaluator) Run(gold, test io.Reader, expected mapping.Header) (*Result, error) {
+	goldAlign, err := readAlignment(gold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gold alignment: %s", err)
+	}
+	testAlign, err := readAlignment(test)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tested alignment: %s", err)
+	}
+	if err := goldAlign.header.Verify(expected); err != nil {
+		return nil, fmt.Errorf("gold alignment: %s", err)
+	}
+	if err := testAlign.header.Verify(expected); err != nil {
+		return nil, fmt.Errorf("tested alignment: %s", err)
+	}
+	if err := testAlign.header.Verify(goldAlign.header); err != nil {
+		return nil, err
+	}
+	ans := &Result{}
+	ans.LinksStrict.NumGold = len(goldAlign.links)
+	ans.LinksLax.NumGold = len(goldAlign.links)
+	for _, link := range goldAlign.links {
+		if testAlign.index[link] {
+			ans.LinksStrict.NumCorrectGold++
+
+		} else {
+			e.report(DisagreementMissing, link)
+		}
+		if testAlign.overlaps(link) {
+			ans.LinksLax.NumCorrectGold++
+		}
+	}
+	ans.LinksStrict.NumTest = len(testAlign.links)
+	ans.LinksLax.NumTest = len(testAlign.links)
+	for _, link := range testAlign.links {
+		if goldAlign.index[link] {
+			ans.LinksStrict.NumCorrectTest++
+
+		} else {
+			e.report(DisagreementSpurious, link)
+		}
+		if goldAlign.overlaps(link) {
+			ans.LinksLax.NumCorrectTest++
+		}
+	}
+	ans.Pairs.NumGold = len(goldAlign.pairs)
+	ans.Pairs.NumTest = len(testAlign.pairs)
+	for p := range testAlign.pairs {
+		if goldAlign.pairs[p] {
+			ans.Pairs.NumCorrectTest++
+		}
+	}
+	ans.Pairs.NumCorrectGold = ans.Pairs.NumCorrectTest
+	ans.LinksStrict.calculate()
+	ans.LinksLax.calculate()
+	ans.Pairs.calculate()
+	return ans, nil
+}
+
+// NewEvaluator creates a new Evaluator instance. Structure string
+// IDs (attr1, attr2) are used to describe disagreements.
+func NewEvaluator(attr1, attr2 attrib.PosAttr, onDisagreement func(d Disagreement)) *Evaluator {
+	return &Evaluator{
+		attr1:          attr1,
+		attr2:          attr2,
+		onDisagreement: onDisagreement,
+	}
+}
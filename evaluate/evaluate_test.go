@@ -0,0 +1,100 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package evaluate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func evaluate(gold, test string) (*Result, []Disagreement, error) {
+	disagreements := make([]Disagreement, 0, 10)
+	e := NewEvaluator(&attribtest.PrefixAttr{Prefix: "cs:s"}, &attribtest.PrefixAttr{Prefix: "en:s"}, func(d Disagreement) {
+		disagreements = append(disagreements, d)
+	})
+	res, err := e.Run(strings.NewReader(gold), strings.NewReader(test), mapping.Header{})
+	return res, disagreements, err
+}
+
+func TestIdenticalAlignments(t *testing.T) {
+	data := "0\t0\n1,2\t1\n-1\t2,3\n3\t4\n"
+	res, dis, err := evaluate(data, data)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(dis))
+	assert.Equal(t, 5, res.LinksStrict.NumGold)
+	assert.Equal(t, 1.0, res.LinksStrict.Precision)
+	assert.Equal(t, 1.0, res.LinksStrict.Recall)
+	assert.Equal(t, 1.0, res.LinksLax.F1)
+	assert.Equal(t, 4, res.Pairs.NumGold)
+	assert.Equal(t, 1.0, res.Pairs.F1)
+}
+
+func TestCompressedNullLinks(t *testing.T) {
+	_, dis, err := evaluate("-1\t0,1\n", "-1\t0\n-1\t1\n")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(dis))
+}
+
+func TestStrictAndLax(t *testing.T) {
+	// gold: 2-1 link, test: two links (1-1, 1-0)
+	res, dis, err := evaluate("0,1\t0\n2\t1\n", "0\t0\n1\t-1\n2\t1\n")
+	assert.Nil(t, err)
+
+	assert.Equal(t, 1, res.LinksStrict.NumCorrectTest)
+	assert.Equal(t, 1, res.LinksStrict.NumCorrectGold)
+	assert.InDelta(t, 1.0/3.0, res.LinksStrict.Precision, 0.0001)
+	assert.InDelta(t, 0.5, res.LinksStrict.Recall, 0.0001)
+	assert.InDelta(t, 0.4, res.LinksStrict.F1, 0.0001)
+
+	assert.Equal(t, 2, res.LinksLax.NumCorrectTest)
+	assert.Equal(t, 2, res.LinksLax.NumCorrectGold)
+	assert.InDelta(t, 2.0/3.0, res.LinksLax.Precision, 0.0001)
+	assert.InDelta(t, 1.0, res.LinksLax.Recall, 0.0001)
+
+	assert.Equal(t, 2, res.Pairs.NumTest)
+	assert.Equal(t, 3, res.Pairs.NumGold)
+	assert.InDelta(t, 1.0, res.Pairs.Precision, 0.0001)
+	assert.InDelta(t, 2.0/3.0, res.Pairs.Recall, 0.0001)
+
+	assert.Equal(t, 3, len(dis))
+	assert.Equal(t, "missing: [0,1 -> 0] (cs:s:0..cs:s:1 -> en:s:0)", dis[0].String())
+	assert.Equal(t, DisagreementSpurious, dis[1].Type)
+	assert.Equal(t, "spurious: [1 -> -1] (cs:s:1 -> -)", dis[2].String())
+}
+
+func TestErrorRecord(t *testing.T) {
+	_, _, err := evaluate("0\t0\n", "0\t0\nERROR\n")
+	assert.Error(t, err)
+}
+
+func TestHeadersMismatch(t *testing.T) {
+	_, _, err := evaluate("#@ corpus1=ic_cs\n0\t0\n", "#@ corpus1=ic_pl\n0\t0\n")
+	assert.Error(t, err)
+}
+
+func TestWriteText(t *testing.T) {
+	res, _, _ := evaluate("0\t0\n", "0\t0\n")
+	var buff bytes.Buffer
+	assert.Nil(t, res.WriteText(&buff))
+	assert.Contains(t, buff.String(), "links (strict)")
+	assert.Contains(t, buff.String(), "1.0000")
+}
@@ -18,10 +18,11 @@
 package export
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/czcorpus/ictools/attrib"
@@ -37,17 +38,31 @@ func createGroupTag(lang1, lang2, ident string) string {
 
 type Export struct {
 	RegPath1    string
-	Corp1       attrib.GoCorpus
-	Attr1       attrib.GoPosAttr
+	Corp1       attrib.Corpus
+	Attr1       attrib.PosAttr
 	RegPath2    string
-	Corp2       attrib.GoCorpus
-	Attr2       attrib.GoPosAttr
+	Corp2       attrib.Corpus
+	Attr2       attrib.PosAttr
 	MappingPath string
+
+	// StructName is a name of aligned structures (e.g. "s"); used
+	// by text exports (FormatTMX)
+	StructName string
+
+	// TextAttr is a positional attribute used to obtain texts
+	// of aligned structures (FormatTMX)
+	TextAttr string
+
+	// Header contains expected information about the mapping
+	// (corpora, structure attribute and sizes). Known values are
+	// verified against the header of the mapping data (if present).
+	Header mapping.Header
+
 	groupFilter GroupFilter
 	pool        *gpool.TextGroupPool
 }
 
-func (e *Export) createPosRange(rng *mapping.PosRange, attr attrib.GoPosAttr, itemize bool) []string {
+func (e *Export) createPosRange(rng *mapping.PosRange, attr attrib.PosAttr, itemize bool) []string {
 	if itemize {
 		items := make([]string, rng.Last-rng.First+1)
 		for i := 0; i < len(items); i++ {
@@ -58,6 +73,20 @@ func (e *Export) createPosRange(rng *mapping.PosRange, attr attrib.GoPosAttr, it
 	return []string{attr.ID2Str(rng.First), attr.ID2Str(rng.Last)}
 }
 
+// createLinkAttrs creates 'status' attribute (and 'certainty' in case
+// a score is available) of a <link> element. If no status is available,
+// "man" is used.
+func createLinkAttrs(meta mapping.LinkMeta) string {
+	status := meta.Status
+	if status == "" {
+		status = mapping.StatusManual
+	}
+	if meta.HasScore {
+		return fmt.Sprintf("status=\"%s\" certainty=\"%s\"", status, strconv.FormatFloat(meta.Score, 'f', -1, 64))
+	}
+	return fmt.Sprintf("status=\"%s\"", status)
+}
+
 func (e *Export) createTag(item *mapping.Mapping, exportType string) []string {
 	var lft, rgt []string
 	var lftArity, rgtArity int
@@ -100,23 +129,24 @@ func (e *Export) createTag(item *mapping.Mapping, exportType string) []string {
 		rgtArity = 1
 	}
 
+	linkAttrs := createLinkAttrs(item.Meta)
 	if item.From.First == -1 {
 		ans := make([]string, len(rgt))
 		for i, rgtItem := range rgt {
-			ans[i] = fmt.Sprintf("<link type=\"0-1\" xtargets=\";%s\" status=\"man\" />", rgtItem)
+			ans[i] = fmt.Sprintf("<link type=\"0-1\" xtargets=\";%s\" %s />", rgtItem, linkAttrs)
 		}
 		return ans
 	}
 	if item.To.First == -1 {
 		ans := make([]string, len(lft))
 		for i, lftItem := range lft {
-			ans[i] = fmt.Sprintf("<link type=\"1-0\" xtargets=\"%s;\" status=\"man\" />", lftItem)
+			ans[i] = fmt.Sprintf("<link type=\"1-0\" xtargets=\"%s;\" %s />", lftItem, linkAttrs)
 		}
 		return ans
 	}
 	if len(lft) <= 2 && len(rgt) <= 2 {
-		return []string{fmt.Sprintf("<link type=\"%d-%d\" xtargets=\"%s;%s\" status=\"man\" />",
-			lftArity, rgtArity, strings.Join(lft, " "), strings.Join(rgt, " "))}
+		return []string{fmt.Sprintf("<link type=\"%d-%d\" xtargets=\"%s;%s\" %s />",
+			lftArity, rgtArity, strings.Join(lft, " "), strings.Join(rgt, " "), linkAttrs)}
 	}
 	log.Print("WARNING: returning empty range - this should not happen ", item)
 	return []string{}
@@ -135,6 +165,7 @@ func (e *Export) ungroupAndAdd(item *mapping.Mapping) {
 					e.pool.AddGroup(currGroup, &mapping.Mapping{
 						From: mapping.PosRange{First: -1, Last: -1},
 						To:   mapping.PosRange{First: currGroupStartIdx, Last: i - 1},
+						Meta: item.Meta,
 					})
 					currGroupStartIdx = i
 				}
@@ -145,6 +176,7 @@ func (e *Export) ungroupAndAdd(item *mapping.Mapping) {
 			e.pool.AddGroup(newGroup, &mapping.Mapping{
 				From: mapping.PosRange{First: -1, Last: -1},
 				To:   mapping.PosRange{First: currGroupStartIdx, Last: item.To.Last},
+				Meta: item.Meta,
 			})
 		}
 
@@ -157,6 +189,7 @@ func (e *Export) ungroupAndAdd(item *mapping.Mapping) {
 					e.pool.AddGroup(currGroup, &mapping.Mapping{
 						From: mapping.PosRange{First: currGroupStartIdx, Last: i - 1},
 						To:   mapping.PosRange{First: -1, Last: -1},
+						Meta: item.Meta,
 					})
 					currGroupStartIdx = i
 				}
@@ -167,6 +200,7 @@ func (e *Export) ungroupAndAdd(item *mapping.Mapping) {
 			e.pool.AddGroup(newGroup, &mapping.Mapping{
 				From: mapping.PosRange{First: currGroupStartIdx, Last: item.From.Last},
 				To:   mapping.PosRange{First: -1, Last: -1},
+				Meta: item.Meta,
 			})
 		}
 
@@ -190,7 +224,7 @@ func (e *Export) getGroupIdent(item *mapping.Mapping) string {
 	return group
 }
 
-func (e *Export) printGroup(lang1, lang2 string, grp *gpool.TextGroup, ignoreEmpty bool, exportType string) {
+func (e *Export) printGroup(w io.Writer, lang1, lang2 string, grp *gpool.TextGroup, ignoreEmpty bool, exportType string) error {
 	var bld strings.Builder
 	grp.ForEach(func(mp *mapping.Mapping) {
 		if !ignoreEmpty || (mp.From.First > -1 && mp.To.First > 1) {
@@ -200,44 +234,95 @@ func (e *Export) printGroup(lang1, lang2 string, grp *gpool.TextGroup, ignoreEmp
 		}
 	})
 	if bld.Len() > 0 {
-		fmt.Println(createGroupTag(lang1, lang2, grp.ID))
-		fmt.Print(bld.String())
-		fmt.Println("</linkGrp>")
+		_, err := fmt.Fprintf(w, "%s\n%s</linkGrp>\n", createGroupTag(lang1, lang2, grp.ID), bld.String())
+		return err
 	}
+	return nil
+}
+
+// readItems reads a numeric mapping (text or binary) from src
+// and calls onItem for each valid item. Invalid items and error
+// records are logged and skipped.
+func (e *Export) readItems(src io.Reader, onItem func(item *mapping.Mapping) error) error {
+	reader, err := mapping.NewReader(src)
+	if err != nil {
+		return err
+	}
+	if err := reader.Header().Verify(e.Header); err != nil {
+		return err
+	}
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			return nil
+
+		} else if _, ok := err.(*mapping.ParseError); ok {
+			log.Print("ERROR: ", err)
+			continue
+
+		} else if err != nil {
+			return err
+
+		} else if item.IsError() {
+			log.Print("ERROR: skipping an error record (", mapping.ErrorMark, ")")
+			continue
+		}
+		if err := onItem(&item); err != nil {
+			return err
+		}
+	}
+}
+
+// traverse reads a numeric mapping from src, ungroups its items
+// and calls onGroup for each text group once it is complete.
+// Items without a recognized group are ignored.
+func (e *Export) traverse(src io.Reader, onGroup func(grp *gpool.TextGroup)) error {
+	e.pool = gpool.NewTextGroupPool()
+	err := e.readItems(src, func(item *mapping.Mapping) error {
+		if e.getGroupIdent(item) != "" {
+			e.ungroupAndAdd(item)
+			for nxt := e.pool.PopNextReady(); nxt != nil; nxt = e.pool.PopNextReady() {
+				onGroup(nxt)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for nxt := e.pool.PopOldest(); nxt != nil; nxt = e.pool.PopOldest() {
+		onGroup(nxt)
+	}
+	return nil
 }
 
 // Run generates a XML-ish output with the same format as the one
-// used as input format for generating numerical alignment files.
+// used as input format for generating numerical alignment files
+// and writes it to out.
 // The algorithm is able to ungroup 'compressed' numeric intervals
 // so if an interval contains multiple texts - all of them should
 // be written to the output.
-func (e *Export) Run(regPath1, regPath2, exportType string, skipEmpty bool) {
+func (e *Export) Run(out io.Writer, regPath1, regPath2, exportType string, skipEmpty bool) error {
 	srcFile, err := os.Open(e.MappingPath)
 	if err != nil {
-		log.Fatal("FATAL: ", err)
+		return err
 	}
+	defer srcFile.Close()
 	e.groupFilter = NewGroupFilter(exportType)
 	lang1 := e.groupFilter.ExtractLangFromRegistry(regPath1)
 	lang2 := e.groupFilter.ExtractLangFromRegistry(regPath2)
 
-	fmt.Println("<?xml version=\"1.0\" encoding=\"utf-8\"?>")
-	fr := bufio.NewScanner(srcFile)
-	var newGroup1 string
-	e.pool = gpool.NewTextGroupPool()
-	for i := 0; fr.Scan(); i++ {
-		item, err := mapping.NewMappingFromString(fr.Text())
-		if err != nil {
-			log.Print("ERROR: ", err)
-		}
-		newGroup1 = e.getGroupIdent(&item)
-		if newGroup1 != "" {
-			e.ungroupAndAdd(&item)
-			for nxt := e.pool.PopNextReady(); nxt != nil; nxt = e.pool.PopNextReady() {
-				e.printGroup(lang1, lang2, nxt, skipEmpty, exportType)
-			}
-		}
+	if _, err := fmt.Fprintln(out, "<?xml version=\"1.0\" encoding=\"utf-8\"?>"); err != nil {
+		return err
 	}
-	for nxt := e.pool.PopOldest(); nxt != nil; nxt = e.pool.PopOldest() {
-		e.printGroup(lang1, lang2, nxt, skipEmpty, exportType)
+	var writeErr error
+	err = e.traverse(srcFile, func(grp *gpool.TextGroup) {
+		if writeErr == nil {
+			writeErr = e.printGroup(out, lang1, lang2, grp, skipEmpty, exportType)
+		}
+	})
+	if err != nil {
+		return err
 	}
+	return writeErr
 }
@@ -0,0 +1,280 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/export/gpool"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/czcorpus/ictools/output"
+)
+
+const (
+	// FormatTSV writes aligned pairs as tab-separated
+	// lines (id1, text1, id2, text2).
+	FormatTSV = "tsv"
+
+	// FormatMoses writes aligned pairs into two line-aligned
+	// files (one per language) as used for MT training.
+	FormatMoses = "moses"
+)
+
+var (
+	textSanitizer = strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+)
+
+// TextExportOptions configures plain-text exports
+type TextExportOptions struct {
+
+	// DropUnaligned specifies whether 1-0 and 0-1 links are ignored
+	DropUnaligned bool
+
+	// MergeNM specifies whether n-m links (n > 1 or m > 1) are written
+	// as single lines with merged texts. Otherwise they are ignored
+	// as they cannot be represented by pairs of single structures.
+	MergeNM bool
+}
+
+// TextPair is a single aligned pair of structures (or structure
+// groups in case of merged n-m links). Empty side has empty
+// ID and Text.
+type TextPair struct {
+	ID1   string
+	Text1 string
+	ID2   string
+	Text2 string
+}
+
+// PairWriter writes aligned pairs to a specific plain-text format
+type PairWriter interface {
+	WritePair(pair *TextPair) error
+	Flush() error
+}
+
+// TSVWriter writes aligned pairs as tab-separated lines
+// (id1, text1, id2, text2).
+type TSVWriter struct {
+	w *bufio.Writer
+}
+
+// WritePair writes a single pair as a line
+func (tw *TSVWriter) WritePair(pair *TextPair) error {
+	_, err := tw.w.WriteString(
+		textSanitizer.Replace(pair.ID1) + "\t" + textSanitizer.Replace(pair.Text1) + "\t" +
+			textSanitizer.Replace(pair.ID2) + "\t" + textSanitizer.Replace(pair.Text2) + "\n")
+	return err
+}
+
+// Flush writes any buffered data to the underlying writer
+func (tw *TSVWriter) Flush() error {
+	return tw.w.Flush()
+}
+
+// NewTSVWriter creates a new TSVWriter instance
+func NewTSVWriter(w io.Writer) *TSVWriter {
+	return &TSVWriter{w: bufio.NewWriter(w)}
+}
+
+// MosesWriter writes aligned pairs into two line-aligned
+// outputs (texts only).
+type MosesWriter struct {
+	w1 *bufio.Writer
+	w2 *bufio.Writer
+}
+
+// WritePair writes a single pair as a line in each of the outputs
+func (mw *MosesWriter) WritePair(pair *TextPair) error {
+	if _, err := mw.w1.WriteString(textSanitizer.Replace(pair.Text1) + "\n"); err != nil {
+		return err
+	}
+	_, err := mw.w2.WriteString(textSanitizer.Replace(pair.Text2) + "\n")
+	return err
+}
+
+// Flush writes any buffered data to the underlying writers
+func (mw *MosesWriter) Flush() error {
+	if err := mw.w1.Flush(); err != nil {
+		return err
+	}
+	return mw.w2.Flush()
+}
+
+// NewMosesWriter creates a new MosesWriter instance
+func NewMosesWriter(w1, w2 io.Writer) *MosesWriter {
+	return &MosesWriter{w1: bufio.NewWriter(w1), w2: bufio.NewWriter(w2)}
+}
+
+func (e *Export) rangeIDs(rng *mapping.PosRange, attr attrib.PosAttr) string {
+	if rng.First == -1 {
+		return ""
+	}
+	return strings.Join(e.createPosRange(rng, attr, true), " ")
+}
+
+func (e *Export) rangeText(rng *mapping.PosRange, corp attrib.Corpus) (string, error) {
+	if rng.First == -1 {
+		return "", nil
+	}
+	return corp.StructText(e.StructName, e.TextAttr, rng.First, rng.Last)
+}
+
+// createPairs transforms a mapping into a list of text pairs
+// according to provided options. One-sided ranges are split into
+// individual structures (as they are compressed 1-0 or 0-1 links).
+func (e *Export) createPairs(item *mapping.Mapping, opts TextExportOptions) ([]*TextPair, error) {
+	if item.From.First == -1 && item.To.First == -1 {
+		return []*TextPair{}, nil
+	}
+	if item.From.First == -1 || item.To.First == -1 {
+		if opts.DropUnaligned {
+			return []*TextPair{}, nil
+		}
+		ans := make([]*TextPair, 0, 10)
+		for i := item.From.First; i <= item.From.Last && i > -1; i++ {
+			text, err := e.Corp1.StructText(e.StructName, e.TextAttr, i, i)
+			if err != nil {
+				return nil, err
+			}
+			ans = append(ans, &TextPair{ID1: e.Attr1.ID2Str(i), Text1: text})
+		}
+		for i := item.To.First; i <= item.To.Last && i > -1; i++ {
+			text, err := e.Corp2.StructText(e.StructName, e.TextAttr, i, i)
+			if err != nil {
+				return nil, err
+			}
+			ans = append(ans, &TextPair{ID2: e.Attr2.ID2Str(i), Text2: text})
+		}
+		return ans, nil
+	}
+	if (item.From.First != item.From.Last || item.To.First != item.To.Last) && !opts.MergeNM {
+		return []*TextPair{}, nil
+	}
+	text1, err := e.rangeText(&item.From, e.Corp1)
+	if err != nil {
+		return nil, err
+	}
+	text2, err := e.rangeText(&item.To, e.Corp2)
+	if err != nil {
+		return nil, err
+	}
+	return []*TextPair{
+		{
+			ID1:   e.rangeIDs(&item.From, e.Attr1),
+			Text1: text1,
+			ID2:   e.rangeIDs(&item.To, e.Attr2),
+			Text2: text2,
+		},
+	}, nil
+}
+
+func (e *Export) writePairs(item *mapping.Mapping, opts TextExportOptions, pw PairWriter) error {
+	pairs, err := e.createPairs(item, opts)
+	if err != nil {
+		return err
+	}
+	for _, pair := range pairs {
+		if err := pw.WritePair(pair); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteText writes aligned pairs of structures from a numeric mapping
+// read from src using a provided PairWriter. In case a group filter is
+// specified (exportType), items are traversed and ordered by their
+// groups (documents) just like in case of the XML export. Otherwise,
+// items are written in the order of the mapping.
+func (e *Export) WriteText(src io.Reader, exportType string, opts TextExportOptions, pw PairWriter) error {
+	var procErr error
+	if exportType != "" {
+		e.groupFilter = NewGroupFilter(exportType)
+		err := e.traverse(src, func(grp *gpool.TextGroup) {
+			grp.ForEach(func(mp *mapping.Mapping) {
+				if procErr == nil {
+					procErr = e.writePairs(mp, opts, pw)
+				}
+			})
+		})
+		if err != nil {
+			return err
+		}
+
+	} else {
+		err := e.readItems(src, func(item *mapping.Mapping) error {
+			return e.writePairs(item, opts, pw)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if procErr != nil {
+		return procErr
+	}
+	return pw.Flush()
+}
+
+// RunText writes aligned pairs (see WriteText) for the mapping
+// file specified in MappingPath. For FormatTSV, data are written to out,
+// for FormatMoses, two files [outPrefix].[lang] are created (both of them
+// appear only in case the export succeeds).
+func (e *Export) RunText(out io.Writer, regPath1, regPath2, exportType, format, outPrefix string, opts TextExportOptions) error {
+	srcFile, err := os.Open(e.MappingPath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	switch format {
+	case FormatTSV:
+		return e.WriteText(srcFile, exportType, opts, NewTSVWriter(out))
+	case FormatMoses:
+		filter := NewGroupFilter(exportType)
+		path1 := outPrefix + "." + filter.ExtractLangFromRegistry(regPath1)
+		path2 := outPrefix + "." + filter.ExtractLangFromRegistry(regPath2)
+		if path1 == path2 {
+			return fmt.Errorf("cannot distinguish output files by language (%s)", path1)
+		}
+		out1, err := output.Create(path1)
+		if err != nil {
+			return err
+		}
+		out2, err := output.Create(path2)
+		if err != nil {
+			out1.Abort()
+			return err
+		}
+		log.Printf("INFO: writing %s and %s", path1, path2)
+		if err := e.WriteText(srcFile, exportType, opts, NewMosesWriter(out1, out2)); err != nil {
+			out1.Abort()
+			out2.Abort()
+			return err
+		}
+		if err := out1.Commit(); err != nil {
+			out2.Abort()
+			return err
+		}
+		return out2.Commit()
+	}
+	return fmt.Errorf("Unknown text export format '%s'", format)
+}
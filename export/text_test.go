@@ -0,0 +1,67 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTextExport() *Export {
+	return &Export{
+		Corp1:      &mockCorpus{texts: []string{"Ahoj.", "Jak se máš?", "Dobře.", "Díky."}},
+		Attr1:      &attribtest.PrefixAttr{Prefix: "cs:doc"},
+		Corp2:      &mockCorpus{texts: []string{"Hello.", "How are", "you?", "Fine."}},
+		Attr2:      &attribtest.PrefixAttr{Prefix: "en:doc"},
+		StructName: "s",
+		TextAttr:   DefaultTextAttr,
+	}
+}
+
+const testTextMapping = "0\t0\n1\t1,2\n2,3\t-1\n-1\t3\n"
+
+func TestWriteTextTSV(t *testing.T) {
+	var out strings.Builder
+	err := createTextExport().WriteText(
+		strings.NewReader(testTextMapping), "", TextExportOptions{}, NewTSVWriter(&out))
+	assert.Nil(t, err)
+	assert.Equal(t, "cs:doc:0\tAhoj.\ten:doc:0\tHello.\n"+
+		"cs:doc:2\tDobře.\t\t\n"+
+		"cs:doc:3\tDíky.\t\t\n"+
+		"\t\ten:doc:3\tFine.\n", out.String())
+}
+
+func TestWriteTextTSVMergedNoUnaligned(t *testing.T) {
+	var out strings.Builder
+	err := createTextExport().WriteText(
+		strings.NewReader(testTextMapping), "", TextExportOptions{DropUnaligned: true, MergeNM: true}, NewTSVWriter(&out))
+	assert.Nil(t, err)
+	assert.Equal(t, "cs:doc:0\tAhoj.\ten:doc:0\tHello.\n"+
+		"cs:doc:1\tJak se máš?\ten:doc:1 en:doc:2\tHow are you?\n", out.String())
+}
+
+func TestWriteTextMoses(t *testing.T) {
+	var out1, out2 strings.Builder
+	err := createTextExport().WriteText(
+		strings.NewReader(testTextMapping), "", TextExportOptions{MergeNM: true}, NewMosesWriter(&out1, &out2))
+	assert.Nil(t, err)
+	assert.Equal(t, "Ahoj.\nJak se máš?\nDobře.\nDíky.\n\n", out1.String())
+	assert.Equal(t, "Hello.\nHow are you?\n\n\nFine.\n", out2.String())
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+const (
+	// FormatXCES is the default export format reconstructing
+	// the original <linkGrp>/<link> alignment file.
+	FormatXCES = "xces"
+
+	// FormatTMX is a TMX 1.4 translation memory with actual
+	// texts of aligned structures.
+	FormatTMX = "tmx"
+
+	// DefaultTextAttr is a positional attribute used to obtain
+	// structure text in case nothing else is specified.
+	DefaultTextAttr = "word"
+)
+
+func writeTMXSeg(w *bufio.Writer, lang, text string) {
+	w.WriteString("<tuv xml:lang=\"")
+	xml.EscapeText(w, []byte(lang))
+	w.WriteString("\"><seg>")
+	xml.EscapeText(w, []byte(text))
+	w.WriteString("</seg></tuv>\n")
+}
+
+func writeTMXMeta(w *bufio.Writer, meta mapping.LinkMeta) {
+	if meta.Status != "" {
+		w.WriteString("<prop type=\"x-status\">")
+		xml.EscapeText(w, []byte(meta.Status))
+		w.WriteString("</prop>\n")
+	}
+	if meta.HasScore {
+		w.WriteString("<prop type=\"x-score\">")
+		w.WriteString(strconv.FormatFloat(meta.Score, 'f', -1, 64))
+		w.WriteString("</prop>\n")
+	}
+}
+
+// writeTU writes a single <tu> element for an aligned item.
+// Items aligned just on one side are ignored (false is returned).
+func (e *Export) writeTU(w *bufio.Writer, item *mapping.Mapping, lang1, lang2 string) (bool, error) {
+	if item.From.First == -1 || item.To.First == -1 {
+		return false, nil
+	}
+	text1, err := e.Corp1.StructText(e.StructName, e.TextAttr, item.From.First, item.From.Last)
+	if err != nil {
+		return false, fmt.Errorf("failed to get text of %s (%s): %s", item.From, e.RegPath1, err)
+	}
+	text2, err := e.Corp2.StructText(e.StructName, e.TextAttr, item.To.First, item.To.Last)
+	if err != nil {
+		return false, fmt.Errorf("failed to get text of %s (%s): %s", item.To, e.RegPath2, err)
+	}
+	w.WriteString("<tu>\n")
+	writeTMXMeta(w, item.Meta)
+	writeTMXSeg(w, lang1, text1)
+	writeTMXSeg(w, lang2, text2)
+	w.WriteString("</tu>\n")
+	return true, nil
+}
+
+// WriteTMX generates a TMX 1.4 document where each aligned item of
+// the numeric mapping read from src becomes a <tu> element containing
+// the texts of the aligned structures (obtained via Corp1 and Corp2).
+// Items with one of the sides empty are skipped.
+func (e *Export) WriteTMX(src io.Reader, out io.Writer, lang1, lang2 string) error {
+	w := bufio.NewWriter(out)
+	w.WriteString("<?xml version=\"1.0\" encoding=\"utf-8\"?>\n")
+	w.WriteString("<tmx version=\"1.4\">\n")
+	w.WriteString("<header creationtool=\"ictools\" creationtoolversion=\"1\" segtype=\"sentence\" ")
+	w.WriteString("o-tmf=\"ictools\" adminlang=\"en\" datatype=\"plaintext\" srclang=\"")
+	xml.EscapeText(w, []byte(lang1))
+	w.WriteString("\" />\n<body>\n")
+
+	numUnits := 0
+	err := e.readItems(src, func(item *mapping.Mapping) error {
+		written, err := e.writeTU(w, item, lang1, lang2)
+		if written {
+			numUnits++
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	w.WriteString("</body>\n</tmx>\n")
+	log.Printf("INFO: written %d translation units", numUnits)
+	return w.Flush()
+}
+
+// RunTMX generates a TMX 1.4 document (see WriteTMX) for the mapping
+// file specified in MappingPath and writes it to out.
+func (e *Export) RunTMX(out io.Writer, regPath1, regPath2, exportType string) error {
+	srcFile, err := os.Open(e.MappingPath)
+	if err != nil {
+		return err
+	}
+	defer srcFile.Close()
+	filter := NewGroupFilter(exportType)
+	return e.WriteTMX(
+		srcFile,
+		out,
+		filter.ExtractLangFromRegistry(regPath1),
+		filter.ExtractLangFromRegistry(regPath2),
+	)
+}
@@ -0,0 +1,100 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package export
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockCorpus struct {
+	texts []string
+}
+
+func (mc *mockCorpus) StructSize(name string) (int, error) {
+	return len(mc.texts), nil
+}
+
+func (mc *mockCorpus) Attr(name string) (attrib.PosAttr, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (mc *mockCorpus) StructSpan(structName string, idx int) (attrib.Span, error) {
+	return attrib.Span{}, fmt.Errorf("not supported")
+}
+
+func (mc *mockCorpus) Tokens(attrName string, span attrib.Span) ([]string, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (mc *mockCorpus) StructText(structName string, attrName string, first int, last int) (string, error) {
+	if last >= len(mc.texts) {
+		return "", fmt.Errorf("structure out of range")
+	}
+	return strings.Join(mc.texts[first:last+1], " "), nil
+}
+
+func TestWriteTMX(t *testing.T) {
+	e := Export{
+		Corp1:      &mockCorpus{texts: []string{"Ahoj.", "Jak se máš?", "Dobře & ty?"}},
+		Corp2:      &mockCorpus{texts: []string{"Hello.", "How are you?", "Fine.", "And you?"}},
+		StructName: "s",
+		TextAttr:   DefaultTextAttr,
+	}
+	src := "0\t0\t\tman\n1\t1\n-1\t2\n2\t2,3\t\tauto:0.5\n"
+	var out strings.Builder
+	err := e.WriteTMX(strings.NewReader(src), &out, "cs", "en")
+	assert.Nil(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="utf-8"?>
+<tmx version="1.4">
+<header creationtool="ictools" creationtoolversion="1" segtype="sentence" o-tmf="ictools" adminlang="en" datatype="plaintext" srclang="cs" />
+<body>
+<tu>
+<prop type="x-status">man</prop>
+<tuv xml:lang="cs"><seg>Ahoj.</seg></tuv>
+<tuv xml:lang="en"><seg>Hello.</seg></tuv>
+</tu>
+<tu>
+<tuv xml:lang="cs"><seg>Jak se máš?</seg></tuv>
+<tuv xml:lang="en"><seg>How are you?</seg></tuv>
+</tu>
+<tu>
+<prop type="x-status">auto</prop>
+<prop type="x-score">0.5</prop>
+<tuv xml:lang="cs"><seg>Dobře &amp; ty?</seg></tuv>
+<tuv xml:lang="en"><seg>Fine. And you?</seg></tuv>
+</tu>
+</body>
+</tmx>
+`, out.String())
+}
+
+func TestWriteTMXTextError(t *testing.T) {
+	e := Export{
+		Corp1:      &mockCorpus{texts: []string{"Ahoj."}},
+		Corp2:      &mockCorpus{texts: []string{"Hello."}},
+		StructName: "s",
+		TextAttr:   DefaultTextAttr,
+	}
+	var out strings.Builder
+	err := e.WriteTMX(strings.NewReader("0\t1\n"), &out, "cs", "en")
+	assert.Error(t, err)
+}
@@ -23,6 +23,7 @@ package fixgaps
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
 
@@ -49,15 +50,44 @@ func NewFixGapsError(item mapping.Mapping, left int, pivot int) *FixGapsError {
 // Data are read from 'file'. If startFromZero is true then
 // the list is always build so it starts from position 0.
 // Otherwise, the list starts from the first found item.
+// Invalid lines are logged and skipped.
 // The function does not print anything to stdout.
 func FromFile(file *os.File, startFromZero bool, struct1Size int, struct2Size int, onItem func(item mapping.Mapping)) {
-	fr := bufio.NewScanner(file)
+	err := fromLines(file, startFromZero, onItem, func(err *mapping.ParseError) error {
+		log.Printf("WARNING: Failed to process line %d: %s", err.Line, err.Err)
+		return nil
+	})
+	if err != nil {
+		log.Print("ERROR: ", err)
+	}
+}
+
+// FromReader is the same as FromFile except from the source of data
+// (a reader of a text numeric mapping). In case of an invalid line,
+// *mapping.ParseError is returned (items processed so far are already
+// passed to onItem).
+func FromReader(src io.Reader, startFromZero bool, struct1Size int, struct2Size int, onItem func(item mapping.Mapping)) error {
+	return fromLines(src, startFromZero, onItem, func(err *mapping.ParseError) error {
+		return err
+	})
+}
+
+// fromLines fills in gaps of text numeric mapping lines read from src.
+// Invalid lines are passed to onError which decides whether the processing
+// should continue (nil) or end with the returned error.
+func fromLines(src io.Reader, startFromZero bool, onItem func(item mapping.Mapping), onError func(err *mapping.ParseError) error) error {
+	fr := bufio.NewScanner(src)
 	lastL1 := -1
 	lastL2 := -1
 	for i := 0; fr.Scan(); i++ {
 		item, err := mapping.NewMappingFromString(fr.Text())
-		if err != nil {
-			fmt.Printf("[WARNING] Failed to process line %d: %s", i, err)
+		if err == mapping.ErrComment {
+			continue
+
+		} else if err != nil {
+			if err := onError(&mapping.ParseError{Line: i, Err: err}); err != nil {
+				return err
+			}
 			continue
 		}
 		if !startFromZero && lastL1 == -1 && lastL2 == -1 {
@@ -80,6 +110,7 @@ func FromFile(file *os.File, startFromZero bool, struct1Size int, struct2Size in
 		}
 		onItem(item)
 	}
+	return fr.Err()
 }
 
 // FromChan is the same as FromFile except from the source
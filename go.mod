@@ -4,5 +4,6 @@ go 1.13
 
 require (
 	github.com/czcorpus/manabuild v0.1.2
+	github.com/klauspost/compress v1.11.13
 	github.com/stretchr/testify v1.8.4
 )
@@ -19,25 +19,40 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/czcorpus/ictools/api"
 	"github.com/czcorpus/ictools/attrib"
 	"github.com/czcorpus/ictools/calign"
+	"github.com/czcorpus/ictools/diff"
+	"github.com/czcorpus/ictools/evaluate"
 	"github.com/czcorpus/ictools/export"
 	"github.com/czcorpus/ictools/fixgaps"
 	"github.com/czcorpus/ictools/mapping"
+	"github.com/czcorpus/ictools/output"
+	"github.com/czcorpus/ictools/pipeline"
+	"github.com/czcorpus/ictools/stats"
 	"github.com/czcorpus/ictools/transalign"
+	"github.com/czcorpus/ictools/triangulate"
+	"github.com/czcorpus/ictools/validate"
 )
 
 const (
 	defaultChanBufferSize = 5000
+
+	// diffInputNumeric specifies numeric mappings as the input of 'diff'
+	diffInputNumeric = "numeric"
 )
 
 var (
@@ -48,37 +63,42 @@ var (
 )
 
 type calignArgs struct {
+	backend         string
 	registryPath1   string
 	registryPath2   string
 	attrName        string
 	mappingFilePath string
 	bufferSize      int
-	quoteStyle      int
+	inputFormat     string
+	idsFilePath1    string
+	idsFilePath2    string
+	outputFormat    string
+	outputPath      string
 }
 
 type corpusPair struct {
-	corp1 attrib.GoCorpus
-	attr1 attrib.GoPosAttr
-	corp2 attrib.GoCorpus
-	attr2 attrib.GoPosAttr
+	corp1 attrib.Corpus
+	attr1 attrib.PosAttr
+	corp2 attrib.Corpus
+	attr2 attrib.PosAttr
 }
 
 func openCorpusPair(args calignArgs) *corpusPair {
 	var err error
 
-	c1, err := attrib.OpenCorpus(args.registryPath1)
+	c1, err := attrib.Open(args.backend, args.registryPath1)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open corpus %s: %s", args.registryPath1, err)
 	}
-	attr1, err := attrib.OpenAttr(c1, args.attrName)
+	attr1, err := c1.Attr(args.attrName)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open attribute %s: %s", args.attrName, err)
 	}
-	c2, err := attrib.OpenCorpus(args.registryPath2)
+	c2, err := attrib.Open(args.backend, args.registryPath2)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open corpus %s: %s", args.registryPath1, err)
 	}
-	attr2, err := attrib.OpenAttr(c2, args.attrName)
+	attr2, err := c2.Attr(args.attrName)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open attribute %s: %s", args.attrName, err)
 	}
@@ -90,26 +110,38 @@ func openCorpusPair(args calignArgs) *corpusPair {
 	}
 }
 
-func openAttribute(registryPath, attrName string) attrib.GoPosAttr {
+func openAttribute(backend, registryPath, attrName string) (attrib.Corpus, attrib.PosAttr) {
 	var err error
 
-	corp, err := attrib.OpenCorpus(registryPath)
+	corp, err := attrib.Open(backend, registryPath)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open corpus %s: %s", registryPath, err)
 	}
-	attr, err := attrib.OpenAttr(corp, attrName)
+	attr, err := corp.Attr(attrName)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open attribute %s: %s", attrName, err)
 	}
-	return attr
+	return corp, attr
 }
 
-func getStructSize(corp attrib.GoCorpus, structAttr string) (int, error) {
+func getStructSize(corp attrib.Corpus, structAttr string) (int, error) {
 	structName := strings.Split(structAttr, ".")[0]
-	return attrib.GetStructSize(corp, structName)
+	return corp.StructSize(structName)
 }
 
-func prepareCalign(corps *corpusPair, mappingFilePath string, quoteStyle int) (*os.File, *calign.Processor) {
+func loadIDList(path string) []string {
+	if path == "" {
+		return nil
+	}
+	ids, err := calign.LoadIDList(path)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to load ID list %s: %s", path, err)
+	}
+	return ids
+}
+
+func prepareCalign(corps *corpusPair, args calignArgs) (*os.File, calign.InputReader) {
+	mappingFilePath := args.mappingFilePath
 	var file *os.File
 	var err error
 
@@ -122,61 +154,381 @@ func prepareCalign(corps *corpusPair, mappingFilePath string, quoteStyle int) (*
 			log.Fatalf("FATAL: Failed to open file %s", mappingFilePath)
 		}
 	}
-	return file, calign.NewProcessor(corps.attr1, corps.attr2, quoteStyle)
+	processor, err := calign.NewInputReader(
+		args.inputFormat,
+		corps.attr1,
+		corps.attr2,
+		loadIDList(args.idsFilePath1),
+		loadIDList(args.idsFilePath2),
+	)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	return file, processor
+}
+
+func createMappingWriter(w io.Writer, format string, header mapping.Header) mapping.Writer {
+	writer, err := mapping.NewWriter(w, format, header)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	return writer
+}
+
+func writeMapping(writer mapping.Writer, item mapping.Mapping) {
+	if err := writer.Write(item); err != nil {
+		log.Fatal("FATAL: Failed to write mapping: ", err)
+	}
+}
+
+func closeMappingWriter(writer mapping.Writer) {
+	if err := writer.Close(); err != nil {
+		log.Fatal("FATAL: Failed to write mapping: ", err)
+	}
+}
+
+// createOutput creates an output file written atomically
+// (an empty path means stdout)
+func createOutput(path string) *output.File {
+	out, err := output.Create(path)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	return out
+}
+
+// commitOutput moves a complete output to its target path
+func commitOutput(out *output.File) {
+	if err := out.Commit(); err != nil {
+		log.Fatal("FATAL: Failed to write output: ", err)
+	}
+	if out.Path() != "" {
+		log.Printf("INFO: Written %s", out.Path())
+	}
+}
+
+// abortOutput removes an incomplete output and exits
+// the program with a fatal error
+func abortOutput(out *output.File, v ...interface{}) {
+	if err := out.Abort(); err != nil {
+		log.Print("ERROR: Failed to remove incomplete output: ", err)
+	}
+	log.Fatal(append([]interface{}{"FATAL: "}, v...)...)
+}
+
+// createExpectedHeader creates a mapping header describing
+// the provided corpora
+func createExpectedHeader(corps *corpusPair, regPath1, regPath2, attrName string) mapping.Header {
+	ans := mapping.Header{
+		Corpus1: filepath.Base(regPath1),
+		Corpus2: filepath.Base(regPath2),
+		Attr:    attrName,
+	}
+	var err error
+	if ans.Size1, err = getStructSize(corps.corp1, attrName); err != nil {
+		log.Fatalf("FATAL: Cannot determine size of structure %s (%s)", attrName, regPath1)
+	}
+	if ans.Size2, err = getStructSize(corps.corp2, attrName); err != nil {
+		log.Fatalf("FATAL: Cannot determine size of structure %s (%s)", attrName, regPath2)
+	}
+	return ans
+}
+
+// createTransalignHeader verifies headers of both transalign inputs
+// and creates a header for the output
+func createTransalignHeader(h1, h2 mapping.Header, ignoreHeader bool) mapping.Header {
+	if !ignoreHeader {
+		if err := transalign.VerifyHeaders(h1, h2); err != nil {
+			log.Fatal("FATAL: ", err, " (use -ignore-header to skip the check)")
+		}
+	}
+	ans := transalign.CreateHeader(h1, h2)
+	ans.Version = version
+	return ans
+}
+
+// readMappingHeader reads just a header of a numeric mapping file
+func readMappingHeader(filePath string) mapping.Header {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to open file %s", filePath)
+	}
+	defer file.Close()
+	reader, err := mapping.NewReader(file)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	return reader.Header()
+}
+
+// resolvePivotSides determines whether pivots of transalign input files
+// are in the left column. Sides specified as transalign.PivotAuto are
+// detected using the file headers.
+func resolvePivotSides(pivot1, pivot2 string, filePath1, filePath2 string, ignoreHeader bool) (bool, bool) {
+	var ans [2]bool
+	for i, pivot := range []string{pivot1, pivot2} {
+		switch pivot {
+		case transalign.PivotLeft:
+			ans[i] = true
+		case transalign.PivotRight, transalign.PivotAuto:
+			ans[i] = false
+		default:
+			log.Fatalf("FATAL: Unknown pivot side '%s'", pivot)
+		}
+	}
+	if pivot1 == transalign.PivotAuto || pivot2 == transalign.PivotAuto {
+		left1, left2, err := transalign.DetectPivotSides(readMappingHeader(filePath1), readMappingHeader(filePath2))
+		if err != nil && !ignoreHeader {
+			log.Fatal("FATAL: ", err, " (use -pivot1, -pivot2 to specify pivot columns)")
+
+		} else if err != nil {
+			log.Print("WARNING: ", err)
+		}
+		if pivot1 == transalign.PivotAuto {
+			ans[0] = left1
+		}
+		if pivot2 == transalign.PivotAuto {
+			ans[1] = left2
+		}
+	}
+	return ans[0], ans[1]
 }
 
-func runTransalign(filePath1 string, filePath2 string) {
+func runTransalign(filePath1 string, filePath2 string, pivot1 string, pivot2 string, streaming bool, outputFormat string, ignoreHeader bool, outputPath string) {
 	var file1, file2 *os.File
 	var err error
 
+	pivotLeft1, pivotLeft2 := resolvePivotSides(pivot1, pivot2, filePath1, filePath2, ignoreHeader)
+	if pivotLeft1 {
+		log.Printf("INFO: %s is a PIVOT -> LANG1 mapping", filePath1)
+	}
+	if pivotLeft2 {
+		log.Printf("INFO: %s is a PIVOT -> LANG2 mapping", filePath2)
+	}
+
 	file1, err = os.Open(filePath1)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open file %s", filePath1)
 	}
+	defer file1.Close()
 	file2, err = os.Open(filePath2)
 	if err != nil {
 		log.Fatalf("FATAL: Failed to open file %s", filePath2)
 	}
-	if file2 != file2 {
+	defer file2.Close()
+
+	out := createOutput(outputPath)
+	err = api.Transalign(context.Background(), file1, file2, out, api.TransalignOptions{
+		PivotLeft1:   pivotLeft1,
+		PivotLeft2:   pivotLeft2,
+		Streaming:    streaming,
+		OutputFormat: outputFormat,
+		IgnoreHeader: ignoreHeader,
+		Version:      version,
+		SourceName1:  filePath1,
+		SourceName2:  filePath2,
+	})
+	if _, ok := err.(*mapping.HeaderMismatchError); ok {
+		abortOutput(out, err, " (use -ignore-header to skip the check)")
 
+	} else if err != nil {
+		abortOutput(out, err)
 	}
-	hm1, err := transalign.NewPivotMapping(file1)
-	if err != nil {
+	commitOutput(out)
+	log.Print("INFO: ...Done")
+}
+
+// writeTransalignResult compresses items produced by a transalign
+// run function and writes them to w
+func writeTransalignResult(w io.Writer, outputFormat string, header mapping.Header, run func(onItem func(item mapping.Mapping)) error) {
+	if err := api.WriteTransalignResult(context.Background(), w, outputFormat, header, run); err != nil {
 		log.Fatal("FATAL: ", err)
 	}
-	err = hm1.Load()
-	if err != nil {
-		log.Fatal("FATAL: Failed to load pivot mapping 1: ", err)
+}
+
+// transalignInputName returns a name of the non-pivot language
+// of a transalign input (the corpus name if known, the file name
+// without extension otherwise)
+func transalignInputName(header mapping.Header, filePath string) string {
+	if header.Corpus1 != "" {
+		return header.Corpus1
 	}
-	hm2, err := transalign.NewPivotMapping(file2)
-	if err != nil {
+	name := filepath.Base(filePath)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// runTransalignAll loads each LANG-PIVOT mapping just once and generates
+// all the pairwise LANG1-LANG2 alignments (using numWorkers parallel
+// workers). The results are written to outputDir as [LANG1]-[LANG2] files.
+func runTransalignAll(outputDir string, filePaths []string, numWorkers int, outputFormat string, ignoreHeader bool) {
+	if len(filePaths) < 2 {
+		log.Fatal("FATAL: At least two LANG-PIVOT mappings are required")
+	}
+	if numWorkers < 1 {
+		log.Fatal("FATAL: Number of workers must be at least 1")
+	}
+	if info, err := os.Stat(outputDir); err != nil || !info.IsDir() {
+		log.Fatalf("FATAL: Output directory %s does not exist", outputDir)
+	}
+	headers := make([]mapping.Header, len(filePaths))
+	for i, filePath := range filePaths {
+		headers[i] = readMappingHeader(filePath)
+	}
+	pivotLeft, err := transalign.DetectPivotSidesAll(headers)
+	if err != nil && !ignoreHeader {
 		log.Fatal("FATAL: ", err)
+
+	} else if err != nil {
+		log.Print("WARNING: ", err)
+		pivotLeft = make([]bool, len(filePaths))
 	}
-	err = hm2.Load()
-	if err != nil {
-		log.Fatal("FATAL: Failed to load pivot mapping 2: ", err)
-	}
-
-	ch1 := make(chan []mapping.Mapping, 5)
-	buff1 := make([]mapping.Mapping, 0, defaultChanBufferSize)
-	go func() {
-		transalign.Run(hm1, hm2, func(item mapping.Mapping) {
-			if !item.IsEmpty() {
-				buff1 = append(buff1, item)
-				if len(buff1) == defaultChanBufferSize {
-					ch1 <- buff1
-					buff1 = make([]mapping.Mapping, 0, defaultChanBufferSize)
+
+	pivotMappings := make([]*transalign.PivotMapping, len(filePaths))
+	names := make([]string, len(filePaths))
+	usedNames := make(map[string]string)
+	for i, filePath := range filePaths {
+		file, err := os.Open(filePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open file %s", filePath)
+		}
+		pm, err := transalign.NewPivotMapping(file)
+		if err != nil {
+			log.Fatal("FATAL: ", err)
+		}
+		pm.SetPivotLeft(pivotLeft[i])
+		if err := pm.Load(); err != nil {
+			log.Fatalf("FATAL: Failed to load pivot mapping %s: %s", filePath, err)
+		}
+		file.Close()
+		pivotMappings[i] = pm
+		names[i] = transalignInputName(pm.Header(), filePath)
+		if prev, ok := usedNames[names[i]]; ok {
+			log.Fatalf("FATAL: Files %s and %s have the same language name %s", prev, filePath, names[i])
+		}
+		usedNames[names[i]] = filePath
+	}
+
+	// pivot mappings are only read from now on so they can be shared
+	jobs := make(chan [2]int, len(filePaths)*len(filePaths))
+	outPaths := make(map[string][2]int)
+	for i := range pivotMappings {
+		for j := range pivotMappings {
+			if i == j {
+				continue
+			}
+			outPath := filepath.Join(outputDir, names[i]+"-"+names[j])
+			if prev, ok := outPaths[outPath]; ok {
+				log.Fatalf("FATAL: Pairs %s, %s and %s, %s would be written to the same file %s",
+					names[prev[0]], names[prev[1]], names[i], names[j], outPath)
+			}
+			outPaths[outPath] = [2]int{i, j}
+			jobs <- [2]int{i, j}
+		}
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				pm1, pm2 := pivotMappings[job[0]], pivotMappings[job[1]]
+				header := createTransalignHeader(pm1.Header(), pm2.Header(), ignoreHeader)
+				outPath := filepath.Join(outputDir, names[job[0]]+"-"+names[job[1]])
+				out := createOutput(outPath)
+				err := api.WriteTransalignResult(context.Background(), out, outputFormat, header, func(onItem func(item mapping.Mapping)) error {
+					transalign.Run(pm1, pm2, onItem)
+					return nil
+				})
+				if err != nil {
+					abortOutput(out, err)
 				}
+				commitOutput(out)
 			}
-		})
-		if len(buff1) > 0 {
-			ch1 <- buff1
-		}
-		close(ch1)
-	}()
-	calign.CompressFromChan(ch1, false, func(item mapping.Mapping) {
-		item.IsGap = false
-		fmt.Println(item)
+		}()
+	}
+	wg.Wait()
+}
+
+// parseInvertedInputs parses a comma-separated list of 1-based
+// positions of inverted compose inputs
+func parseInvertedInputs(spec string, numInputs int) []bool {
+	ans := make([]bool, numInputs)
+	for _, item := range strings.Split(spec, ",") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		pos, err := strconv.Atoi(strings.TrimSpace(item))
+		if err != nil || pos < 1 || pos > numInputs {
+			log.Fatalf("FATAL: Invalid position of an inverted input '%s'", item)
+		}
+		ans[pos-1] = true
+	}
+	return ans
+}
+
+// runCompose chains numeric mappings LANG1-LANG2, LANG2-LANG3, ...,
+// LANGn-1-LANGn into a single LANG1-LANGn mapping. Inputs stored in
+// the opposite direction are either detected using headers or specified
+// via invertedSpec.
+func runCompose(filePaths []string, invertedSpec string, outputFormat string, ignoreHeader bool) {
+	if len(filePaths) < 2 {
+		log.Fatal("FATAL: At least two mappings are required")
+	}
+	headers := make([]mapping.Header, len(filePaths))
+	for i, filePath := range filePaths {
+		headers[i] = readMappingHeader(filePath)
+	}
+	var inverted []bool
+	if invertedSpec != "" {
+		inverted = parseInvertedInputs(invertedSpec, len(filePaths))
+
+	} else {
+		var err error
+		inverted, err = transalign.DetectChainInversions(headers)
+		if err != nil && !ignoreHeader {
+			log.Fatal("FATAL: ", err, " (use -inverted to specify inverted inputs)")
+
+		} else if err != nil {
+			log.Print("WARNING: ", err)
+			inverted = make([]bool, len(filePaths))
+		}
+	}
+	header, err := transalign.ComposeHeader(headers, inverted)
+	if err != nil && !ignoreHeader {
+		log.Fatal("FATAL: ", err, " (use -ignore-header to skip the check)")
+	}
+	header.Version = version
+
+	pivotMappings := make([]*transalign.PivotMapping, len(filePaths))
+	for i, filePath := range filePaths {
+		if inverted[i] {
+			log.Printf("INFO: %s is an inverted mapping", filePath)
+		}
+		file, err := os.Open(filePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open file %s", filePath)
+		}
+		pm, err := transalign.NewPivotMapping(file)
+		if err != nil {
+			log.Fatal("FATAL: ", err)
+		}
+		// the first mapping is used as LANG1 -> LANG2, the other ones
+		// as LANGi+1 -> LANGi (i.e. the previous language is the pivot)
+		if i == 0 {
+			pm.SetPivotLeft(inverted[i])
+
+		} else {
+			pm.SetPivotLeft(!inverted[i])
+		}
+		if err := pm.Load(); err != nil {
+			log.Fatalf("FATAL: Failed to load mapping %s: %s", filePath, err)
+		}
+		file.Close()
+		pivotMappings[i] = pm
+	}
+	writeTransalignResult(os.Stdout, outputFormat, header, func(onItem func(item mapping.Mapping)) error {
+		return transalign.Compose(pivotMappings, onItem)
 	})
 	log.Print("INFO: ...Done")
 }
@@ -184,83 +536,423 @@ func runTransalign(filePath1 string, filePath2 string) {
 // runImport runs [calign] > [fixgaps] > [compress]? functions.
 func runImport(args calignArgs) {
 	corps := openCorpusPair(args)
-	file, processor := prepareCalign(corps, args.mappingFilePath, args.quoteStyle)
-	ch1 := make(chan []mapping.Mapping, 5)
-	buff1 := make([]mapping.Mapping, 0, defaultChanBufferSize)
-	go func() {
-		err := processor.ProcessFile(file, args.bufferSize, func(item mapping.Mapping, i int) {
-			buff1 = append(buff1, item)
-			if len(buff1) == defaultChanBufferSize {
-				ch1 <- buff1
-				buff1 = make([]mapping.Mapping, 0, defaultChanBufferSize)
-			}
-		})
+	file := os.Stdin
+	if args.mappingFilePath != "" {
+		var err error
+		file, err = os.Open(args.mappingFilePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open file %s", args.mappingFilePath)
+		}
+		defer file.Close()
+	}
+	header := createExpectedHeader(corps, args.registryPath1, args.registryPath2, args.attrName)
+	header.Version = version
+	ids1 := loadIDList(args.idsFilePath1)
+	ids2 := loadIDList(args.idsFilePath2)
+	out := createOutput(args.outputPath)
+	err := api.Import(context.Background(), file, out, api.ImportOptions{
+		InputFormat:  args.inputFormat,
+		Attr1:        corps.attr1,
+		Attr2:        corps.attr2,
+		IDs1:         ids1,
+		IDs2:         ids2,
+		Header:       header,
+		OutputFormat: args.outputFormat,
+		BufferSize:   args.bufferSize,
+		SourceName:   filepath.Base(file.Name()),
+		OnOverlap: func(err *fixgaps.FixGapsError) {
+			log.Print("ERROR: ", err)
+			log.Printf("INFO: original structure identifiers are: item: [%s, %s -- %s, %s], reached positions: [%s, %s]",
+				corps.attr1.ID2Str(err.Item.From.First), corps.attr1.ID2Str(err.Item.From.Last),
+				corps.attr2.ID2Str(err.Item.To.First), corps.attr2.ID2Str(err.Item.To.Last),
+				corps.attr1.ID2Str(err.Left), corps.attr2.ID2Str(err.Pivot))
+		},
+	})
+	if err != nil {
+		abortOutput(out, err)
+	}
+	commitOutput(out)
+}
+
+// runConvert converts a numeric mapping (text or binary; detected
+// automatically) into a specified format
+func runConvert(filePath string, outputFormat string) {
+	file := os.Stdin
+	if filePath != "" {
+		var err error
+		file, err = os.Open(filePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open file %s", filePath)
+		}
+		defer file.Close()
+	}
+	reader, err := mapping.NewReader(file)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	writer := createMappingWriter(os.Stdout, outputFormat, reader.Header())
+	numItems := 0
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			break
+
+		} else if err != nil {
+			log.Fatal("FATAL: ", err)
+		}
+		writeMapping(writer, item)
+		numItems++
+	}
+	closeMappingWriter(writer)
+	log.Printf("INFO: converted %d items", numItems)
+}
+
+// runInvert swaps sides of a numeric mapping (text or binary; detected
+// automatically). The result is ordered by the new left side and gap
+// items are compressed in the same way 'import' does it.
+func runInvert(filePath string, outputFormat string) {
+	file := os.Stdin
+	if filePath != "" {
+		var err error
+		file, err = os.Open(filePath)
 		if err != nil {
+			log.Fatalf("FATAL: Failed to open file %s", filePath)
+		}
+		defer file.Close()
+	}
+	reader, err := mapping.NewReader(file)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	items := make([]mapping.Mapping, 0, defaultChanBufferSize)
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			break
+
+		} else if err != nil {
 			log.Fatal("FATAL: ", err)
+		}
+		if item.IsError() {
+			log.Fatalf("FATAL: The '%s' mark found on line %d", mapping.ErrorMark, reader.Line())
+		}
+		items = append(items, item)
+	}
+	header := reader.Header().Inverted()
+	header.Version = version
+	writer := createMappingWriter(os.Stdout, outputFormat, header)
+	inverted := make([]mapping.Mapping, 0, len(items))
+	mapping.Invert(items, func(item mapping.Mapping) {
+		inverted = append(inverted, item)
+	})
+	err = pipeline.New(pipeline.Items(inverted)).
+		Then(pipeline.Compress(true)).
+		Run(context.Background(), pipeline.Write(writer))
+	if err != nil {
+		log.Fatal("FATAL: Failed to write mapping: ", err)
+	}
+	closeMappingWriter(writer)
+	log.Printf("INFO: inverted %d items", len(items))
+}
 
-		} else if len(buff1) > 0 {
-			ch1 <- buff1
+// runValidate checks a numeric alignment against both corpora
+// and prints all the found problems. In case of any problem,
+// the program exits with a non-zero status.
+func runValidate(args calignArgs, ignoreHeader bool) {
+	corps := openCorpusPair(args)
+	expected := createExpectedHeader(corps, args.registryPath1, args.registryPath2, args.attrName)
+	file := os.Stdin
+	if args.mappingFilePath != "" {
+		var err error
+		file, err = os.Open(args.mappingFilePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open file %s", args.mappingFilePath)
 		}
-		close(ch1)
-	}()
+		defer file.Close()
+	}
+	validator := validate.NewValidator(corps.attr1, corps.attr2, expected.Size1, expected.Size2, func(p validate.Problem) {
+		fmt.Println(p)
+	})
+	if ignoreHeader {
+		expected = mapping.Header{}
+	}
+	validator.Run(file, expected)
+	if validator.NumProblems() > 0 {
+		log.Fatalf("FATAL: Found %d problems in %d items", validator.NumProblems(), validator.NumItems())
+	}
+	log.Printf("INFO: No problems found in %d items", validator.NumItems())
+}
 
-	ch2 := make(chan []mapping.Mapping, 5)
-	go func() {
+// runCrossings reports aligned items crossing document boundaries
+// (documents are identified using a group filter specified by exportType).
+// In case of any such item, the program exits with a non-zero status.
+func runCrossings(args calignArgs, exportType string, maxRangeSize int) {
+	if exportType == "" {
+		log.Fatal("FATAL: Document IDs cannot be extracted without -export-type")
+	}
+	corps := openCorpusPair(args)
+	file := os.Stdin
+	if args.mappingFilePath != "" {
 		var err error
-		buff2 := make([]mapping.Mapping, 0, defaultChanBufferSize)
-		s1Size, err := getStructSize(corps.corp1, args.attrName)
+		file, err = os.Open(args.mappingFilePath)
 		if err != nil {
-			log.Fatalf("FATAL: Cannot determine size of structure %s (%s)", args.attrName, args.registryPath1)
+			log.Fatalf("FATAL: Failed to open file %s", args.mappingFilePath)
 		}
-		s2Size, err := getStructSize(corps.corp2, args.attrName)
+		defer file.Close()
+	}
+	detector := validate.NewCrossingDetector(corps.attr1, corps.attr2, export.NewGroupFilter(exportType), func(p validate.Problem) {
+		fmt.Println(p)
+	})
+	detector.MaxRangeSize = maxRangeSize
+	detector.Run(file)
+	if detector.NumProblems() > 0 {
+		log.Fatalf("FATAL: Found %d problems in %d items", detector.NumProblems(), detector.NumItems())
+	}
+	log.Printf("INFO: No problems found in %d items", detector.NumItems())
+}
+
+// runStats prints statistics of a numeric alignment either as a text
+// or in the JSON format. In case exportType is specified, statistics
+// of individual documents are included too.
+func runStats(args calignArgs, exportType string, ignoreHeader bool, jsonOutput bool) {
+	corps := openCorpusPair(args)
+	expected := createExpectedHeader(corps, args.registryPath1, args.registryPath2, args.attrName)
+	file := os.Stdin
+	if args.mappingFilePath != "" {
+		var err error
+		file, err = os.Open(args.mappingFilePath)
 		if err != nil {
-			log.Fatalf("FATAL: Cannot determine size of structure %s (%s)", args.attrName, args.registryPath2)
+			log.Fatalf("FATAL: Failed to open file %s", args.mappingFilePath)
 		}
+		defer file.Close()
+	}
+	var filter export.GroupFilter
+	if exportType != "" {
+		filter = export.NewGroupFilter(exportType)
+	}
+	collector := stats.NewCollector(expected.Corpus1, expected.Corpus2, corps.attr1, corps.attr2,
+		expected.Size1, expected.Size2, filter)
+	if ignoreHeader {
+		expected = mapping.Header{}
+	}
+	if err := collector.Run(file, expected); err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	var err error
+	if jsonOutput {
+		err = collector.Result().WriteJSON(os.Stdout)
 
-		errors := make([]error, 0, 10)
-		fixgaps.FromChan(ch1, true, s1Size, s2Size, func(item mapping.Mapping, err *fixgaps.FixGapsError) {
-			if err != nil {
-				log.Print("ERROR: ", err)
-				log.Printf("INFO: original structure identifiers are: item: [%s, %s -- %s, %s], reached positions: [%s, %s]",
-					corps.attr1.ID2Str(err.Item.From.First), corps.attr1.ID2Str(err.Item.From.Last),
-					corps.attr2.ID2Str(err.Item.To.First), corps.attr2.ID2Str(err.Item.To.Last),
-					corps.attr1.ID2Str(err.Left), corps.attr2.ID2Str(err.Pivot))
-				buff2 = append(buff2, mapping.NewErrorMapping())
-				errors = append(errors, err)
+	} else {
+		err = collector.Result().WriteText(os.Stdout)
+	}
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+}
 
-			} else {
-				buff2 = append(buff2, item)
-			}
-			if len(buff2) == defaultChanBufferSize {
-				ch2 <- buff2
-				buff2 = make([]mapping.Mapping, 0, defaultChanBufferSize)
-			}
-		})
-		if len(buff2) > 0 {
-			ch2 <- buff2
+// runEvaluate compares a tested numeric alignment with a gold one
+// and prints all the disagreements followed by evaluation scores
+func runEvaluate(args calignArgs, goldFilePath string, ignoreHeader bool) {
+	corps := openCorpusPair(args)
+	expected := createExpectedHeader(corps, args.registryPath1, args.registryPath2, args.attrName)
+	if ignoreHeader {
+		expected = mapping.Header{}
+	}
+	goldFile, err := os.Open(goldFilePath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to open file %s", goldFilePath)
+	}
+	defer goldFile.Close()
+	testFile, err := os.Open(args.mappingFilePath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to open file %s", args.mappingFilePath)
+	}
+	defer testFile.Close()
+	evaluator := evaluate.NewEvaluator(corps.attr1, corps.attr2, func(d evaluate.Disagreement) {
+		fmt.Println(d)
+	})
+	result, err := evaluator.Run(goldFile, testFile, expected)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	fmt.Println()
+	if err := result.WriteText(os.Stdout); err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+}
+
+// loadDiffVersion reads one version of an alignment compared
+// by 'diff' and passes its items to onItem. Numeric mappings are
+// read directly, other formats are processed via calign (which
+// requires corpora).
+func loadDiffVersion(corps *corpusPair, args calignArgs, onItem func(item mapping.Mapping)) mapping.Header {
+	if args.inputFormat == diffInputNumeric {
+		file, err := os.Open(args.mappingFilePath)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to open file %s", args.mappingFilePath)
 		}
-		close(ch2)
-		if len(errors) > 0 {
-			log.Fatalf("FATAL: Finished with %d errors. The result cannot be used to produce a correct alignment.", len(errors))
+		defer file.Close()
+		header, err := diff.ReadNumeric(file, onItem)
+		if err != nil {
+			log.Fatalf("FATAL: Failed to read file %s: %s", args.mappingFilePath, err)
 		}
-	}()
-	calign.CompressFromChan(ch2, true, func(item mapping.Mapping) {
-		fmt.Println(item)
+		return header
+	}
+	if corps == nil {
+		log.Fatalf("FATAL: Input format '%s' requires corpora to be specified", args.inputFormat)
+	}
+	file, processor := prepareCalign(corps, args)
+	defer file.Close()
+	err := processor.Process(file, filepath.Base(file.Name()), args.bufferSize, func(item mapping.Mapping, i int) {
+		onItem(item)
 	})
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	return mapping.Header{}
+}
+
+// runDiff compares two versions of an alignment and prints changed
+// links followed by a summary. Corpora are optional (registryPath1 == "")
+// and allow resolving positions to structure IDs, identifying documents
+// (exportType) and reading non-numeric formats.
+func runDiff(args calignArgs, oldFilePath, newFilePath string, exportType string, ignoreHeader bool) {
+	var corps *corpusPair
+	var expected mapping.Header
+	d := diff.NewDiff(nil, nil, nil)
+	if args.registryPath1 != "" {
+		corps = openCorpusPair(args)
+		if !ignoreHeader {
+			expected = createExpectedHeader(corps, args.registryPath1, args.registryPath2, args.attrName)
+		}
+		var filter export.GroupFilter
+		if exportType != "" {
+			filter = export.NewGroupFilter(exportType)
+		}
+		d = diff.NewDiff(corps.attr1, corps.attr2, filter)
+	}
+	args.mappingFilePath = oldFilePath
+	oldHeader := loadDiffVersion(corps, args, d.AddOld)
+	args.mappingFilePath = newFilePath
+	newHeader := loadDiffVersion(corps, args, d.AddNew)
+	if !ignoreHeader {
+		for _, err := range []error{oldHeader.Verify(expected), newHeader.Verify(expected), newHeader.Verify(oldHeader)} {
+			if err != nil {
+				log.Fatal("FATAL: ", err)
+			}
+		}
+	}
+	summary, err := d.Write(os.Stdout, oldFilePath, newFilePath)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	if err := summary.WriteText(os.Stdout); err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+}
+
+// loadPivotMapping opens and loads a LANG-PIVOT (or PIVOT-LANG
+// in case pivotLeft is true) mapping file
+func loadPivotMapping(filePath string, pivotLeft bool) *transalign.PivotMapping {
+	file, err := os.Open(filePath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to open file %s", filePath)
+	}
+	defer file.Close()
+	pm, err := transalign.NewPivotMapping(file)
+	if err != nil {
+		log.Fatal("FATAL: ", err)
+	}
+	pm.SetPivotLeft(pivotLeft)
+	if err := pm.Load(); err != nil {
+		log.Fatalf("FATAL: Failed to load pivot mapping %s: %s", filePath, err)
+	}
+	return pm
+}
+
+// runTriangulate compares a direct LANG1-LANG2 alignment (args.mappingFilePath)
+// with the one obtained via transalign from LANG1-PIVOT and LANG2-PIVOT
+// mappings and prints disagreeing segments followed by agreement rates
+// (per document in case exportType is specified).
+func runTriangulate(args calignArgs, pivotFilePath1, pivotFilePath2 string, pivot1, pivot2 string, exportType string, ignoreHeader bool) {
+	corps := openCorpusPair(args)
+	var expected mapping.Header
+	if !ignoreHeader {
+		expected = createExpectedHeader(corps, args.registryPath1, args.registryPath2, args.attrName)
+	}
+	var filter export.GroupFilter
+	if exportType != "" {
+		filter = export.NewGroupFilter(exportType)
+	}
+	checker := triangulate.NewChecker(corps.attr1, corps.attr2, filter)
+
+	file, err := os.Open(args.mappingFilePath)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to open file %s", args.mappingFilePath)
+	}
+	defer file.Close()
+	directHeader, err := diff.ReadNumeric(file, checker.AddDirect)
+	if err != nil {
+		log.Fatalf("FATAL: Failed to read file %s: %s", args.mappingFilePath, err)
+	}
 
+	pivotLeft1, pivotLeft2 := resolvePivotSides(pivot1, pivot2, pivotFilePath1, pivotFilePath2, ignoreHeader)
+	pm1 := loadPivotMapping(pivotFilePath1, pivotLeft1)
+	pm2 := loadPivotMapping(pivotFilePath2, pivotLeft2)
+	pivotedHeader := createTransalignHeader(pm1.Header(), pm2.Header(), ignoreHeader)
+	if !ignoreHeader {
+		if err := directHeader.Verify(expected); err != nil {
+			log.Fatal("FATAL: direct alignment: ", err)
+		}
+		if err := pivotedHeader.Verify(expected); err != nil {
+			log.Fatal("FATAL: pivoted alignment: ", err)
+		}
+	}
+	transalign.Run(pm1, pm2, func(item mapping.Mapping) {
+		if !item.IsEmpty() {
+			checker.AddPivoted(item)
+		}
+	})
+	if err := checker.Result().WriteText(os.Stdout); err != nil {
+		log.Fatal("FATAL: ", err)
+	}
 }
 
-func runSearch(corpusRegistry string, attr string, itemIdx int) {
-	attrObj := openAttribute(corpusRegistry, attr)
-	fmt.Printf("\n\nPosition #%d: %s\n\n", itemIdx, attrObj.ID2Str(itemIdx))
+func runSearch(backend, corpusRegistry string, attr string, itemIdx int, textAttr string) {
+	corp, attrObj := openAttribute(backend, corpusRegistry, attr)
+	fmt.Printf("\n\nPosition #%d: %s\n", itemIdx, attrObj.ID2Str(itemIdx))
+	span, err := corp.StructSpan(strings.Split(attr, ".")[0], itemIdx)
+	if err != nil {
+		log.Print("WARNING: failed to get structure span: ", err)
+		fmt.Println()
+		return
+	}
+	fmt.Printf("Tokens: [%d, %d)\n", span.Beg, span.End)
+	tokens, err := corp.Tokens(textAttr, span)
+	if err != nil {
+		log.Print("WARNING: failed to get structure text: ", err)
+		fmt.Println()
+		return
+	}
+	fmt.Printf("Text: %s\n\n", strings.Join(tokens, " "))
 }
 
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage:\n\t%s [options] import [LANG registry] [PIVOT registry] [attr] [LANG-PIVOT mapping file]?\n", filepath.Base(os.Args[0]))
-		fmt.Fprintf(os.Stderr, "\t%s [options] transalign [LANG1-PIVOT alignment file] [LANG2-PIVOT alignment file]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] transalign [LANG1-PIVOT alignment file] [LANG2-PIVOT alignment file] (see also -pivot1, -pivot2)\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] transalign-all [output directory] [LANG1-PIVOT alignment file] [LANG2-PIVOT alignment file] ...\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] compose [LANG1-LANG2 alignment file] [LANG2-LANG3 alignment file] ... (see also -inverted)\n", filepath.Base(os.Args[0]))
 		fmt.Fprintf(os.Stderr, "\t%s [options] search [LANG registry] [attr] [srch position]\n", filepath.Base(os.Args[0]))
 		fmt.Fprintf(os.Stderr, "\t%s [options] export [LANG1 registry] [LANG2 registry] [attr] [LANG1-LANG2 numeric mapping file]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] validate [LANG1 registry] [LANG2 registry] [attr] [LANG1-LANG2 numeric mapping file]?\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] crossings [LANG1 registry] [LANG2 registry] [attr] [LANG1-LANG2 numeric mapping file]?\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] stats [LANG1 registry] [LANG2 registry] [attr] [LANG1-LANG2 numeric mapping file]?\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] evaluate [LANG1 registry] [LANG2 registry] [attr] [gold numeric mapping file] [tested numeric mapping file]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] diff [[LANG1 registry] [LANG2 registry] [attr]]? [old mapping file] [new mapping file]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] triangulate [LANG1 registry] [LANG2 registry] [attr] [LANG1-LANG2 numeric mapping file] [LANG1-PIVOT alignment file] [LANG2-PIVOT alignment file]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] convert [numeric mapping file]?\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "\t%s [options] invert [LANG1-LANG2 numeric mapping file]?\n", filepath.Base(os.Args[0]))
 		fmt.Fprintf(os.Stderr, "\t%s version\n", filepath.Base(os.Args[0]))
 		flag.PrintDefaults()
 	}
@@ -269,15 +961,71 @@ func main() {
 	var registryPath string
 	flag.StringVar(&registryPath, "registry-path", "", "Path to Manatee registry files (allows using just filenames for registry values in 'import')")
 	var quoteStyle int
-	flag.IntVar(&quoteStyle, "quote-style", 1, "Input XML quote style: 1 - single, 2 - double")
+	flag.IntVar(&quoteStyle, "quote-style", 0, "Deprecated and ignored (both quote styles are detected automatically)")
+	var inputFormat string
+	flag.StringVar(&inputFormat, "input-format", calign.InputFormatXCES,
+		fmt.Sprintf("Import input format: %s (fast, line-based, one <link> per line), %s (full XML parser), %s (hunalign ladder), %s (hunalign -text output), %s, %s (src<TAB>trg[<TAB>score] sentence indices)",
+			calign.InputFormatXCES, calign.InputFormatXML, calign.InputFormatHunalign, calign.InputFormatBisentence,
+			calign.InputFormatVecalign, calign.InputFormatIndex))
+	var idsFilePath1 string
+	flag.StringVar(&idsFilePath1, "ids1", "", "A file with ordered structure IDs of LANG sentences (sentence aligner input formats; if omitted, sentence index = structure position)")
+	var idsFilePath2 string
+	flag.StringVar(&idsFilePath2, "ids2", "", "A file with ordered structure IDs of PIVOT sentences (sentence aligner input formats; if omitted, sentence index = structure position)")
 	var exportType string
 	flag.StringVar(&exportType, "export-type", "",
-		fmt.Sprintf("Select specific tools to export data. Currently supported types: %s", export.ExportTypeIntercorp))
+		fmt.Sprintf("Select specific tools to export data (export) or to identify documents (crossings, stats, diff, triangulate). Currently supported types: %s", export.ExportTypeIntercorp))
+	var exportFormat string
+	flag.StringVar(&exportFormat, "export-format", export.FormatXCES,
+		fmt.Sprintf("Export output format: %s (alignment XML), %s (TMX 1.4 with structure texts), %s (id1, text1, id2, text2), %s (two line-aligned text files)",
+			export.FormatXCES, export.FormatTMX, export.FormatTSV, export.FormatMoses))
+	var mergeNM bool
+	flag.BoolVar(&mergeNM, "merge-nm", false, "Write n-m links as single lines with merged texts (export formats tsv, moses; otherwise such links are ignored)")
+	var mosesPrefix string
+	flag.StringVar(&mosesPrefix, "moses-prefix", "corpus", "Path prefix of files written by the moses export format ([prefix].[lang])")
+	var textAttr string
+	flag.StringVar(&textAttr, "text-attr", export.DefaultTextAttr, "Positional attribute used to obtain structure texts (export format tmx, search)")
 	var skipEmpty bool
 	flag.BoolVar(&skipEmpty, "skip-empty", false, "If set then ignore any alignment of type [-1, X] or [X, -1]")
+	var streaming bool
+	flag.BoolVar(&streaming, "streaming", false, "Run transalign with bounded memory (reads both files sequentially; requires data produced by 'import')")
+	var pivot1 string
+	flag.StringVar(&pivot1, "pivot1", transalign.PivotAuto,
+		fmt.Sprintf("Pivot column of the first transalign (triangulate) input: %s (LANG1-PIVOT), %s (PIVOT-LANG1), %s (detected using headers; %s if not available)",
+			transalign.PivotRight, transalign.PivotLeft, transalign.PivotAuto, transalign.PivotRight))
+	var pivot2 string
+	flag.StringVar(&pivot2, "pivot2", transalign.PivotAuto,
+		fmt.Sprintf("Pivot column of the second transalign (triangulate) input: %s (LANG2-PIVOT), %s (PIVOT-LANG2), %s (detected using headers; %s if not available)",
+			transalign.PivotRight, transalign.PivotLeft, transalign.PivotAuto, transalign.PivotRight))
+	var invertedInputs string
+	flag.StringVar(&invertedInputs, "inverted", "", "Comma-separated positions (starting from 1) of compose inputs stored in the opposite direction, e.g. LANG3-LANG2 (detected using headers if empty)")
+	var numWorkers int
+	flag.IntVar(&numWorkers, "workers", runtime.NumCPU(), "Number of language pairs processed in parallel (transalign-all)")
+	var outputFormat string
+	flag.StringVar(&outputFormat, "output-format", mapping.FormatText,
+		fmt.Sprintf("Numeric mapping output format (import, transalign, transalign-all, compose, convert, invert): %s, %s (text without header and checksum), %s (compact)",
+			mapping.FormatText, mapping.FormatPlain, mapping.FormatBinary))
+	var outputPath string
+	flag.StringVar(&outputPath, "o", "", "Output file (import, transalign, export); written only if the command succeeds, compressed by gzip or zstd in case of the .gz or .zst extension (stdout if empty)")
+	var maxRangeSize int
+	flag.IntVar(&maxRangeSize, "max-range-size", validate.DefaultMaxRangeSize, "Report aligned ranges longer than this number of structures (crossings; 0 = no limit)")
+	var ignoreHeader bool
+	flag.BoolVar(&ignoreHeader, "ignore-header", false, "Do not verify that numeric mapping headers match the corpora (transalign, compose, export, validate, stats, evaluate, diff, triangulate)")
+	var diffInputFormat string
+	flag.StringVar(&diffInputFormat, "diff-input-format", diffInputNumeric,
+		fmt.Sprintf("Format of alignments compared by diff: %s (numeric mapping) or any import input format (requires corpora)", diffInputNumeric))
+	var jsonOutput bool
+	flag.BoolVar(&jsonOutput, "json", false, "Print statistics in the JSON format (stats)")
+	var backend string
+	flag.StringVar(&backend, "backend", attrib.DefaultBackend,
+		fmt.Sprintf("Corpus data access backend: %s (requires Manatee library), %s (reads data files directly)",
+			attrib.BackendManatee, attrib.BackendNative))
 
 	flag.Parse()
 
+	if quoteStyle != 0 {
+		log.Print("WARNING: -quote-style is deprecated and ignored, quote style is detected automatically")
+	}
+
 	if len(flag.Args()) == 0 {
 		fmt.Println("Missing action, try -h for help")
 		os.Exit(1)
@@ -286,31 +1034,110 @@ func main() {
 		t1 := time.Now().UnixNano()
 		switch flag.Arg(0) {
 		case "transalign":
-			runTransalign(flag.Arg(1), flag.Arg(2))
+			runTransalign(flag.Arg(1), flag.Arg(2), pivot1, pivot2, streaming, outputFormat, ignoreHeader, outputPath)
+		case "transalign-all":
+			var filePaths []string
+			if flag.NArg() > 2 {
+				filePaths = flag.Args()[2:]
+			}
+			runTransalignAll(flag.Arg(1), filePaths, numWorkers, outputFormat, ignoreHeader)
+		case "compose":
+			var filePaths []string
+			if flag.NArg() > 1 {
+				filePaths = flag.Args()[1:]
+			}
+			runCompose(filePaths, invertedInputs, outputFormat, ignoreHeader)
 		case "import":
 			runImport(calignArgs{
+				backend:         backend,
 				registryPath1:   filepath.Join(registryPath, flag.Arg(1)),
 				registryPath2:   filepath.Join(registryPath, flag.Arg(2)),
 				attrName:        flag.Arg(3),
 				mappingFilePath: flag.Arg(4),
 				bufferSize:      lineBufferSize,
-				quoteStyle:      quoteStyle,
+				inputFormat:     inputFormat,
+				idsFilePath1:    idsFilePath1,
+				idsFilePath2:    idsFilePath2,
+				outputFormat:    outputFormat,
+				outputPath:      outputPath,
 			})
+		case "validate":
+			runValidate(calignArgs{
+				backend:         backend,
+				registryPath1:   filepath.Join(registryPath, flag.Arg(1)),
+				registryPath2:   filepath.Join(registryPath, flag.Arg(2)),
+				attrName:        flag.Arg(3),
+				mappingFilePath: flag.Arg(4),
+			}, ignoreHeader)
+		case "crossings":
+			runCrossings(calignArgs{
+				backend:         backend,
+				registryPath1:   filepath.Join(registryPath, flag.Arg(1)),
+				registryPath2:   filepath.Join(registryPath, flag.Arg(2)),
+				attrName:        flag.Arg(3),
+				mappingFilePath: flag.Arg(4),
+			}, exportType, maxRangeSize)
+		case "stats":
+			runStats(calignArgs{
+				backend:         backend,
+				registryPath1:   filepath.Join(registryPath, flag.Arg(1)),
+				registryPath2:   filepath.Join(registryPath, flag.Arg(2)),
+				attrName:        flag.Arg(3),
+				mappingFilePath: flag.Arg(4),
+			}, exportType, ignoreHeader, jsonOutput)
+		case "evaluate":
+			runEvaluate(calignArgs{
+				backend:         backend,
+				registryPath1:   filepath.Join(registryPath, flag.Arg(1)),
+				registryPath2:   filepath.Join(registryPath, flag.Arg(2)),
+				attrName:        flag.Arg(3),
+				mappingFilePath: flag.Arg(5),
+			}, flag.Arg(4), ignoreHeader)
+		case "diff":
+			args := calignArgs{
+				backend:      backend,
+				bufferSize:   lineBufferSize,
+				inputFormat:  diffInputFormat,
+				idsFilePath1: idsFilePath1,
+				idsFilePath2: idsFilePath2,
+			}
+			if flag.NArg() == 3 {
+				runDiff(args, flag.Arg(1), flag.Arg(2), exportType, ignoreHeader)
+
+			} else {
+				args.registryPath1 = filepath.Join(registryPath, flag.Arg(1))
+				args.registryPath2 = filepath.Join(registryPath, flag.Arg(2))
+				args.attrName = flag.Arg(3)
+				runDiff(args, flag.Arg(4), flag.Arg(5), exportType, ignoreHeader)
+			}
+		case "triangulate":
+			runTriangulate(calignArgs{
+				backend:         backend,
+				registryPath1:   filepath.Join(registryPath, flag.Arg(1)),
+				registryPath2:   filepath.Join(registryPath, flag.Arg(2)),
+				attrName:        flag.Arg(3),
+				mappingFilePath: flag.Arg(4),
+			}, flag.Arg(5), flag.Arg(6), pivot1, pivot2, exportType, ignoreHeader)
+		case "convert":
+			runConvert(flag.Arg(1), outputFormat)
+		case "invert":
+			runInvert(flag.Arg(1), outputFormat)
 		case "search":
 			itemIdx, err := strconv.Atoi(flag.Arg(3))
 			if err != nil {
 				log.Fatalf("FATAL: failed to parse item position: %s. Expected integer number.", flag.Arg(1))
 			}
-			runSearch(flag.Arg(1), flag.Arg(2), itemIdx)
+			runSearch(backend, flag.Arg(1), flag.Arg(2), itemIdx, textAttr)
 		case "export":
 			regPath1 := filepath.Join(registryPath, flag.Arg(1))
 			regPath2 := filepath.Join(registryPath, flag.Arg(2))
 			corps := openCorpusPair(calignArgs{
+				backend:       backend,
 				registryPath1: regPath1,
 				registryPath2: regPath2,
 				attrName:      flag.Arg(3),
 			})
-			export := export.Export{
+			exp := export.Export{
 				RegPath1:    regPath1,
 				Corp1:       corps.corp1,
 				Attr1:       corps.attr1,
@@ -318,8 +1145,34 @@ func main() {
 				Corp2:       corps.corp2,
 				Attr2:       corps.attr2,
 				MappingPath: flag.Arg(4),
+				StructName:  strings.Split(flag.Arg(3), ".")[0],
+				TextAttr:    textAttr,
+			}
+			if !ignoreHeader {
+				exp.Header = createExpectedHeader(corps, regPath1, regPath2, flag.Arg(3))
+			}
+			if exportFormat == export.FormatMoses && outputPath != "" {
+				log.Fatal("FATAL: The moses export format writes two files, use -moses-prefix instead of -o")
+			}
+			out := createOutput(outputPath)
+			var err error
+			switch exportFormat {
+			case export.FormatXCES:
+				err = exp.Run(out, regPath1, regPath2, exportType, skipEmpty)
+			case export.FormatTMX:
+				err = exp.RunTMX(out, regPath1, regPath2, exportType)
+			case export.FormatTSV, export.FormatMoses:
+				err = exp.RunText(out, regPath1, regPath2, exportType, exportFormat, mosesPrefix, export.TextExportOptions{
+					DropUnaligned: skipEmpty,
+					MergeNM:       mergeNM,
+				})
+			default:
+				abortOutput(out, fmt.Sprintf("Unknown export format '%s'", exportFormat))
+			}
+			if err != nil {
+				abortOutput(out, err)
 			}
-			export.Run(regPath1, regPath2, exportType, skipEmpty)
+			commitOutput(out)
 		case "version":
 			fmt.Printf("%s (Manatee: %s, build date: %s, last commit: %s)\n", version, manateeVersion, buildDate, gitCommit)
 			return
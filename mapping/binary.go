@@ -0,0 +1,384 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mapping
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// BinaryVersion is a version of the binary format
+	// written by BinaryWriter
+	BinaryVersion = 2
+
+	flagGap       = 0x01
+	flagFromEmpty = 0x02
+	flagToEmpty   = 0x04
+	flagStatus    = 0x08
+	flagScore     = 0x10
+	flagError     = 0x20
+	flagEnd       = 0x80
+
+	maxBinaryString = 1 << 16
+)
+
+var (
+	// binaryMagic starts each binary mapping file (a text
+	// mapping never starts with a zero byte)
+	binaryMagic = []byte{0x00, 'I', 'C', 'M'}
+)
+
+// BinaryWriter writes mapping items in a compact binary format:
+//
+// header: magic (4 bytes), version (1 byte), number of header values
+// (uvarint) followed by key-value pairs (strings encoded as uvarint
+// length + UTF-8 data; see Header)
+//
+// item: flags (1 byte; gap, empty sides, metadata presence, error record,
+// end of data), then for each non-empty side the distance of the first
+// position from the end of the previous range on the same side
+// (varint) and the range size - 1 (uvarint), then a status (uvarint length
+// + data) and a score (float64, little endian) if present.
+//
+// The data are terminated by an item with the 'end' flag followed
+// by a checksum of the items (uint32, little endian).
+type BinaryWriter struct {
+	w        *bufio.Writer
+	buff     []byte
+	nextFrom int
+	nextTo   int
+	checksum *checksum
+}
+
+func (bw *BinaryWriter) writeUvarint(v uint64) error {
+	n := binary.PutUvarint(bw.buff, v)
+	_, err := bw.w.Write(bw.buff[:n])
+	return err
+}
+
+func (bw *BinaryWriter) writeVarint(v int64) error {
+	n := binary.PutVarint(bw.buff, v)
+	_, err := bw.w.Write(bw.buff[:n])
+	return err
+}
+
+func (bw *BinaryWriter) writeString(s string) error {
+	if len(s) > maxBinaryString {
+		return fmt.Errorf("String too long for the binary mapping format: %d bytes", len(s))
+	}
+	if err := bw.writeUvarint(uint64(len(s))); err != nil {
+		return err
+	}
+	_, err := bw.w.WriteString(s)
+	return err
+}
+
+func (bw *BinaryWriter) writeRange(rng PosRange, next *int) error {
+	if rng.First < 0 || rng.Last < rng.First {
+		return fmt.Errorf("Cannot encode range %d,%d", rng.First, rng.Last)
+	}
+	if err := bw.writeVarint(int64(rng.First - *next)); err != nil {
+		return err
+	}
+	*next = rng.Last + 1
+	return bw.writeUvarint(uint64(rng.Last - rng.First))
+}
+
+func (bw *BinaryWriter) writeHeader(header Header) error {
+	if _, err := bw.w.Write(binaryMagic); err != nil {
+		return err
+	}
+	if err := bw.w.WriteByte(BinaryVersion); err != nil {
+		return err
+	}
+	pairs := header.pairs()
+	if err := bw.writeUvarint(uint64(len(pairs))); err != nil {
+		return err
+	}
+	for _, kv := range pairs {
+		if err := bw.writeString(kv[0]); err != nil {
+			return err
+		}
+		if err := bw.writeString(kv[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bw *BinaryWriter) Write(item Mapping) error {
+	bw.checksum.add(item)
+	if item.IsError() {
+		return bw.w.WriteByte(flagError)
+	}
+	var flags byte
+	if item.IsGap {
+		flags |= flagGap
+	}
+	if item.From.First == -1 {
+		flags |= flagFromEmpty
+	}
+	if item.To.First == -1 {
+		flags |= flagToEmpty
+	}
+	if item.Meta.Status != "" {
+		flags |= flagStatus
+	}
+	if item.Meta.HasScore {
+		flags |= flagScore
+	}
+	if err := bw.w.WriteByte(flags); err != nil {
+		return err
+	}
+	if flags&flagFromEmpty == 0 {
+		if err := bw.writeRange(item.From, &bw.nextFrom); err != nil {
+			return err
+		}
+	}
+	if flags&flagToEmpty == 0 {
+		if err := bw.writeRange(item.To, &bw.nextTo); err != nil {
+			return err
+		}
+	}
+	if flags&flagStatus != 0 {
+		if err := bw.writeString(item.Meta.Status); err != nil {
+			return err
+		}
+	}
+	if flags&flagScore != 0 {
+		binary.LittleEndian.PutUint64(bw.buff, math.Float64bits(item.Meta.Score))
+		if _, err := bw.w.Write(bw.buff[:8]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close writes the terminating item with the checksum
+// and flushes buffered data
+func (bw *BinaryWriter) Close() error {
+	if err := bw.w.WriteByte(flagEnd); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(bw.buff, bw.checksum.hash.Sum32())
+	if _, err := bw.w.Write(bw.buff[:4]); err != nil {
+		return err
+	}
+	return bw.w.Flush()
+}
+
+// NewBinaryWriter creates a new BinaryWriter instance
+// and writes the header.
+func NewBinaryWriter(w io.Writer, header Header) (*BinaryWriter, error) {
+	ans := &BinaryWriter{
+		w:        bufio.NewWriter(w),
+		buff:     make([]byte, binary.MaxVarintLen64),
+		checksum: newChecksum(),
+	}
+	if err := ans.writeHeader(header); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
+
+// ----------------------------------------------
+
+// BinaryReader reads mapping items in the binary format (see BinaryWriter)
+type BinaryReader struct {
+	r        *bufio.Reader
+	header   Header
+	buff     []byte
+	nextFrom int
+	nextTo   int
+	finished bool
+	checksum *checksum
+	itemNum  int
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (br *BinaryReader) readString() (string, error) {
+	size, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		return "", unexpectedEOF(err)
+	}
+	if size > maxBinaryString {
+		return "", fmt.Errorf("Invalid binary mapping data (string size %d)", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(br.r, data); err != nil {
+		return "", unexpectedEOF(err)
+	}
+	return string(data), nil
+}
+
+func (br *BinaryReader) readSize() (int, error) {
+	v, err := binary.ReadUvarint(br.r)
+	if err != nil {
+		return 0, unexpectedEOF(err)
+	}
+	if v > math.MaxInt32 {
+		return 0, fmt.Errorf("Invalid binary mapping data (value %d)", v)
+	}
+	return int(v), nil
+}
+
+func (br *BinaryReader) readRange(next *int) (PosRange, error) {
+	dist, err := binary.ReadVarint(br.r)
+	if err != nil {
+		return PosRange{}, unexpectedEOF(err)
+	}
+	size, err := br.readSize()
+	if err != nil {
+		return PosRange{}, err
+	}
+	first := int64(*next) + dist
+	if first < 0 || first+int64(size) > math.MaxInt32 {
+		return PosRange{}, fmt.Errorf("Invalid binary mapping data (position %d)", first)
+	}
+	ans := PosRange{First: int(first), Last: int(first) + size}
+	*next = ans.Last + 1
+	return ans, nil
+}
+
+func (br *BinaryReader) readHeader() error {
+	magic := make([]byte, len(binaryMagic)+1)
+	if _, err := io.ReadFull(br.r, magic); err != nil {
+		return unexpectedEOF(err)
+	}
+	if string(magic[:len(binaryMagic)]) != string(binaryMagic) {
+		return fmt.Errorf("Not a binary mapping data")
+	}
+	if magic[len(binaryMagic)] != BinaryVersion {
+		return fmt.Errorf("Unsupported binary mapping format version %d", magic[len(binaryMagic)])
+	}
+	numPairs, err := br.readSize()
+	if err != nil {
+		return err
+	}
+	for i := 0; i < numPairs; i++ {
+		key, err := br.readString()
+		if err != nil {
+			return err
+		}
+		value, err := br.readString()
+		if err != nil {
+			return err
+		}
+		if err := br.header.set(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Line returns an item number (starting from 1) of the last read item
+func (br *BinaryReader) Line() int {
+	return br.itemNum
+}
+
+// Header returns information stored in the binary data
+func (br *BinaryReader) Header() Header {
+	return br.header
+}
+
+func (br *BinaryReader) Read() (Mapping, error) {
+	if br.finished {
+		return Mapping{}, io.EOF
+	}
+	flags, err := br.r.ReadByte()
+	if err != nil {
+		return Mapping{}, unexpectedEOF(err)
+	}
+	if flags != flagEnd {
+		br.itemNum++
+	}
+	if flags == flagEnd {
+		br.finished = true
+		if _, err := io.ReadFull(br.r, br.buff[:4]); err != nil {
+			return Mapping{}, unexpectedEOF(err)
+		}
+		expected := fmt.Sprintf("%s%08x", checksumPrefix, binary.LittleEndian.Uint32(br.buff[:4]))
+		if err := br.checksum.verify(expected); err != nil {
+			return Mapping{}, err
+		}
+		return Mapping{}, io.EOF
+
+	} else if flags == flagError {
+		ans := NewErrorMapping()
+		br.checksum.add(ans)
+		return ans, nil
+
+	} else if flags&(flagEnd|flagError) != 0 {
+		return Mapping{}, fmt.Errorf("Invalid binary mapping data (flags %x)", flags)
+	}
+	ans := Mapping{
+		From:  NewEmptyPosRange(),
+		To:    NewEmptyPosRange(),
+		IsGap: flags&flagGap != 0,
+	}
+	if flags&flagFromEmpty == 0 {
+		if ans.From, err = br.readRange(&br.nextFrom); err != nil {
+			return Mapping{}, err
+		}
+	}
+	if flags&flagToEmpty == 0 {
+		if ans.To, err = br.readRange(&br.nextTo); err != nil {
+			return Mapping{}, err
+		}
+	}
+	if flags&flagStatus != 0 {
+		if ans.Meta.Status, err = br.readString(); err != nil {
+			return Mapping{}, err
+		}
+	}
+	if flags&flagScore != 0 {
+		if _, err := io.ReadFull(br.r, br.buff[:8]); err != nil {
+			return Mapping{}, unexpectedEOF(err)
+		}
+		ans.Meta.Score = math.Float64frombits(binary.LittleEndian.Uint64(br.buff[:8]))
+		ans.Meta.HasScore = true
+	}
+	br.checksum.add(ans)
+	if err := br.header.checkPositions(ans); err != nil {
+		return Mapping{}, &ParseError{Line: br.itemNum, Err: err}
+	}
+	return ans, nil
+}
+
+// NewBinaryReader creates a new BinaryReader instance
+// and reads the header.
+func NewBinaryReader(src io.Reader) (*BinaryReader, error) {
+	r, ok := src.(*bufio.Reader)
+	if !ok {
+		r = bufio.NewReader(src)
+	}
+	ans := &BinaryReader{r: r, buff: make([]byte, 8), checksum: newChecksum()}
+	if err := ans.readHeader(); err != nil {
+		return nil, err
+	}
+	return ans, nil
+}
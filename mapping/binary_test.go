@@ -0,0 +1,136 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mapping
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testTextMapping = "0,2\t0\tg\tman\n" +
+	"3\t1,2\t\tauto:0.85\n" +
+	"-1\t3\n" +
+	"4\t-1\n" +
+	"5,9\t4,8\n" +
+	"ERROR\n" +
+	"2\t10\t\t:0.5\n" +
+	"-1\t11,20\tg\n"
+
+func convert(t *testing.T, src string, format string, header Header) []byte {
+	reader, err := NewReader(strings.NewReader(src))
+	assert.Nil(t, err)
+	var ans bytes.Buffer
+	writer, err := NewWriter(&ans, format, header)
+	assert.Nil(t, err)
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		assert.Nil(t, writer.Write(item))
+	}
+	assert.Nil(t, writer.Close())
+	return ans.Bytes()
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	header := Header{Corpus1: "intercorp_pl", Corpus2: "intercorp_cs", Attr: "s.id", Size1: 10, Size2: 21, Version: "0.2.0"}
+	data := convert(t, testTextMapping, FormatBinary, header)
+
+	reader, err := NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.IsType(t, &BinaryReader{}, reader)
+	assert.Equal(t, header, reader.Header())
+
+	assert.Equal(t, testTextMapping, string(convert(t, string(data), FormatPlain, Header{})))
+}
+
+func TestBinaryIsCompact(t *testing.T) {
+	var src strings.Builder
+	for i := 100000; i < 101000; i++ {
+		src.WriteString(NewMapping(i, i, i+7, i+7).String() + "\n")
+	}
+	data := convert(t, src.String(), FormatBinary, Header{})
+	assert.True(t, len(data) < src.Len()/2)
+}
+
+func TestBinaryErrorRecord(t *testing.T) {
+	data := convert(t, "ERROR\n", FormatBinary, Header{})
+	reader, err := NewBinaryReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	item, err := reader.Read()
+	assert.Nil(t, err)
+	assert.True(t, item.IsError())
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBinaryTruncatedData(t *testing.T) {
+	data := convert(t, testTextMapping, FormatBinary, Header{})
+	reader, err := NewReader(bytes.NewReader(data[:len(data)-1]))
+	assert.Nil(t, err)
+	for {
+		_, err = reader.Read()
+		if err != nil {
+			break
+		}
+	}
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestBinaryUnsupportedVersion(t *testing.T) {
+	data := convert(t, testTextMapping, FormatBinary, Header{})
+	data[len(binaryMagic)] = BinaryVersion + 1
+	_, err := NewReader(bytes.NewReader(data))
+	assert.Error(t, err)
+}
+
+func TestBinaryWriterInvalidRange(t *testing.T) {
+	writer, err := NewBinaryWriter(&bytes.Buffer{}, Header{})
+	assert.Nil(t, err)
+	assert.Error(t, writer.Write(NewMapping(3, 2, 0, 0)))
+}
+
+func TestTextReaderParseError(t *testing.T) {
+	reader, err := NewReader(strings.NewReader("0\t0\nfoo\n1\t1\n"))
+	assert.Nil(t, err)
+	_, err = reader.Read()
+	assert.Nil(t, err)
+	_, err = reader.Read()
+	assert.IsType(t, &ParseError{}, err)
+	assert.Equal(t, 2, err.(*ParseError).Line)
+	item, err := reader.Read()
+	assert.Nil(t, err)
+	assert.Equal(t, NewMapping(1, 1, 1, 1), item)
+}
+
+func TestNewReaderEmptySource(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(""))
+	assert.Nil(t, err)
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestNewWriterUnknownFormat(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, "foo", Header{})
+	assert.Error(t, err)
+}
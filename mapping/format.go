@@ -0,0 +1,289 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mapping
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	// FormatText is the line-based numeric format as produced
+	// by Mapping.String() (e.g. "1,3<TAB>4<TAB>g").
+	FormatText = "text"
+
+	// FormatPlain is the text format without the header and
+	// the checksum (for tools which do not accept comment lines)
+	FormatPlain = "plain"
+
+	// FormatBinary is a compact binary encoding of numeric
+	// mappings (see BinaryWriter).
+	FormatBinary = "binary"
+)
+
+// ParseError describes an invalid item of a numeric mapping. Readers
+// are able to continue after the error (i.e. the item can be skipped).
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s (line %d)", e.Err, e.Line)
+}
+
+// Reader reads items of a numeric mapping
+type Reader interface {
+
+	// Header returns information about the mapping data
+	Header() Header
+
+	// Read returns next item. At the end of data, io.EOF is returned
+	// (or an error in case the data checksum does not match).
+	// An error record (ErrorMark) is returned as an item (see Mapping.IsError).
+	Read() (Mapping, error)
+
+	// Line returns a line number (text format) or an item number
+	// (binary format) of the last read item
+	Line() int
+}
+
+// Writer writes items of a numeric mapping
+type Writer interface {
+	Write(item Mapping) error
+
+	// Close finishes the data and flushes all the buffered data.
+	// The underlying writer is not closed.
+	Close() error
+}
+
+// TextReader reads mapping items in the text format. Comment lines
+// are skipped; header lines (see Header) are expected at the beginning
+// of the data. In case the data contain a checksum (as the last comment
+// line), it is verified once all the data are read. Data with a header
+// must contain the checksum (otherwise they are considered incomplete).
+type TextReader struct {
+	reader   *bufio.Scanner
+	lineNum  int
+	header   Header
+	checksum *checksum
+
+	// expectedChecksum is a checksum found in data
+	expectedChecksum string
+
+	// checksumRequired specifies that a missing checksum
+	// should be reported (at the end of the data)
+	checksumRequired bool
+
+	// pending is a line read while looking for the header
+	pending    string
+	hasPending bool
+	err        error
+}
+
+// Header returns information found in the header
+// (an empty value is returned if there is no header)
+func (tr *TextReader) Header() Header {
+	return tr.header
+}
+
+// processComment extracts metadata from a comment line
+func (tr *TextReader) processComment(line string, inHeader bool) error {
+	if !strings.HasPrefix(line, metadataMark) {
+		return nil
+	}
+	kv := strings.SplitN(strings.TrimSpace(line[len(metadataMark):]), "=", 2)
+	if len(kv) != 2 {
+		return &ParseError{Line: tr.lineNum, Err: fmt.Errorf("Invalid metadata line")}
+	}
+	if kv[0] == headerKeyChecksum {
+		tr.expectedChecksum = kv[1]
+		return nil
+
+	} else if !inHeader {
+		return &ParseError{Line: tr.lineNum, Err: fmt.Errorf("Header value '%s' found outside the header", kv[0])}
+	}
+	if err := tr.header.set(kv[0], kv[1]); err != nil {
+		return &ParseError{Line: tr.lineNum, Err: err}
+	}
+	return nil
+}
+
+func (tr *TextReader) nextLine() (string, bool) {
+	if tr.hasPending {
+		tr.hasPending = false
+		return tr.pending, true
+	}
+	if !tr.reader.Scan() {
+		return "", false
+	}
+	tr.lineNum++
+	return tr.reader.Text(), true
+}
+
+func (tr *TextReader) readHeader() {
+	for tr.reader.Scan() {
+		tr.lineNum++
+		line := tr.reader.Text()
+		if !IsComment(line) {
+			tr.pending = line
+			tr.hasPending = true
+			return
+		}
+		if err := tr.processComment(line, true); err != nil {
+			tr.err = err
+			return
+		}
+	}
+}
+
+// Line returns a line number of the last read item
+func (tr *TextReader) Line() int {
+	return tr.lineNum
+}
+
+func (tr *TextReader) Read() (Mapping, error) {
+	if tr.err != nil {
+		err := tr.err
+		tr.err = nil
+		return Mapping{}, err
+	}
+	for {
+		line, ok := tr.nextLine()
+		if !ok {
+			break
+		}
+		if IsComment(line) {
+			if err := tr.processComment(line, false); err != nil {
+				return Mapping{}, err
+			}
+			continue
+		}
+		var item Mapping
+		if line == ErrorMark {
+			item = NewErrorMapping()
+
+		} else {
+			var err error
+			item, err = NewMappingFromString(line)
+			if err != nil {
+				return Mapping{}, &ParseError{Line: tr.lineNum, Err: err}
+			}
+		}
+		tr.checksum.add(item)
+		if err := tr.header.checkPositions(item); err != nil {
+			return Mapping{}, &ParseError{Line: tr.lineNum, Err: err}
+		}
+		return item, nil
+	}
+	if err := tr.reader.Err(); err != nil {
+		return Mapping{}, err
+	}
+	if tr.expectedChecksum != "" {
+		if err := tr.checksum.verify(tr.expectedChecksum); err != nil {
+			tr.expectedChecksum = ""
+			return Mapping{}, err
+		}
+
+	} else if tr.checksumRequired {
+		tr.checksumRequired = false
+		return Mapping{}, fmt.Errorf("Missing checksum (the data with a header are probably incomplete)")
+	}
+	return Mapping{}, io.EOF
+}
+
+// NewTextReader creates a new TextReader instance
+// and reads the header (if any).
+func NewTextReader(src io.Reader) *TextReader {
+	ans := &TextReader{reader: bufio.NewScanner(src), checksum: newChecksum()}
+	ans.readHeader()
+	ans.checksumRequired = !ans.header.IsEmpty()
+	return ans
+}
+
+// TextWriter writes mapping items in the text format. A non-empty
+// header is written at the beginning, a checksum of the items
+// is written as the last line.
+type TextWriter struct {
+	w          *bufio.Writer
+	checksum   *checksum
+	noMetadata bool
+}
+
+func (tw *TextWriter) Write(item Mapping) error {
+	tw.checksum.add(item)
+	_, err := tw.w.WriteString(item.String() + "\n")
+	return err
+}
+
+// Close writes the checksum and flushes buffered data
+func (tw *TextWriter) Close() error {
+	if !tw.noMetadata {
+		if _, err := tw.w.WriteString(metadataMark + " " + headerKeyChecksum + "=" + tw.checksum.String() + "\n"); err != nil {
+			return err
+		}
+	}
+	return tw.w.Flush()
+}
+
+// NewTextWriter creates a new TextWriter instance. The header
+// is written immediately (unless it is empty).
+func NewTextWriter(w io.Writer, header Header) *TextWriter {
+	ans := &TextWriter{w: bufio.NewWriter(w), checksum: newChecksum()}
+	for _, kv := range header.pairs() {
+		ans.w.WriteString(metadataMark + " " + kv[0] + "=" + kv[1] + "\n")
+	}
+	return ans
+}
+
+// NewPlainTextWriter creates a TextWriter which writes just
+// the mapping items (without header and checksum) for tools
+// which do not accept comment lines.
+func NewPlainTextWriter(w io.Writer) *TextWriter {
+	return &TextWriter{w: bufio.NewWriter(w), checksum: newChecksum(), noMetadata: true}
+}
+
+// NewReader creates a reader for numeric mapping data. The format
+// (text or binary) is detected automatically.
+func NewReader(src io.Reader) (Reader, error) {
+	br := bufio.NewReader(src)
+	magic, err := br.Peek(len(binaryMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if bytes.Equal(magic, binaryMagic) {
+		return NewBinaryReader(br)
+	}
+	return NewTextReader(br), nil
+}
+
+// NewWriter creates a writer for a specified format (FormatText,
+// FormatPlain, FormatBinary).
+func NewWriter(w io.Writer, format string, header Header) (Writer, error) {
+	switch format {
+	case FormatText:
+		return NewTextWriter(w, header), nil
+	case FormatPlain:
+		return NewPlainTextWriter(w), nil
+	case FormatBinary:
+		return NewBinaryWriter(w, header)
+	}
+	return nil, fmt.Errorf("Unknown mapping format '%s'", format)
+}
@@ -0,0 +1,219 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mapping
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	headerKeyCorpus1  = "corpus1"
+	headerKeyCorpus2  = "corpus2"
+	headerKeyAttr     = "attr"
+	headerKeySize1    = "size1"
+	headerKeySize2    = "size2"
+	headerKeyVersion  = "ictools"
+	headerKeyChecksum = "checksum"
+
+	checksumPrefix = "crc32:"
+)
+
+// Header describes the data of a numeric mapping file (the corpora
+// and the structure attribute it was created for). Empty values
+// (and zero sizes) mean 'unknown'.
+//
+// In the text format, the header is written as a block of comment
+// lines '#@ key=value' at the beginning of the data. In the binary
+// format, the same key-value pairs are stored right after the format
+// version.
+type Header struct {
+	Corpus1 string
+	Corpus2 string
+
+	// Attr is a structure attribute used to identify aligned
+	// structures (e.g. "s.id")
+	Attr string
+
+	Size1 int
+	Size2 int
+
+	// Version is a version of ictools which created the data
+	Version string
+}
+
+// IsEmpty tests whether there is any information in the header
+func (h Header) IsEmpty() bool {
+	return h == Header{}
+}
+
+// StructName returns a name of aligned structures
+// (e.g. "s" for "s.id")
+func (h Header) StructName() string {
+	return strings.Split(h.Attr, ".")[0]
+}
+
+func (h Header) pairs() [][2]string {
+	ans := make([][2]string, 0, 6)
+	add := func(key, value string) {
+		if value != "" {
+			ans = append(ans, [2]string{key, value})
+		}
+	}
+	add(headerKeyCorpus1, h.Corpus1)
+	add(headerKeyCorpus2, h.Corpus2)
+	add(headerKeyAttr, h.Attr)
+	if h.Size1 > 0 {
+		add(headerKeySize1, strconv.Itoa(h.Size1))
+	}
+	if h.Size2 > 0 {
+		add(headerKeySize2, strconv.Itoa(h.Size2))
+	}
+	add(headerKeyVersion, h.Version)
+	return ans
+}
+
+// set sets a header value by its key. Unknown keys are ignored.
+func (h *Header) set(key, value string) error {
+	var err error
+	switch key {
+	case headerKeyCorpus1:
+		h.Corpus1 = value
+	case headerKeyCorpus2:
+		h.Corpus2 = value
+	case headerKeyAttr:
+		h.Attr = value
+	case headerKeySize1:
+		h.Size1, err = strconv.Atoi(value)
+	case headerKeySize2:
+		h.Size2, err = strconv.Atoi(value)
+	case headerKeyVersion:
+		h.Version = value
+	}
+	if err != nil || h.Size1 < 0 || h.Size2 < 0 {
+		return fmt.Errorf("Invalid header value %s=%s", key, value)
+	}
+	return nil
+}
+
+// Inverted returns a header of an alignment with swapped sides
+func (h Header) Inverted() Header {
+	ans := h
+	ans.Corpus1, ans.Corpus2 = h.Corpus2, h.Corpus1
+	ans.Size1, ans.Size2 = h.Size2, h.Size1
+	return ans
+}
+
+// HeaderMismatchError is returned in case a mapping header
+// does not match expected values (see Header.Verify)
+type HeaderMismatchError struct {
+	// Mismatches contains descriptions of all the mismatching values
+	Mismatches []string
+}
+
+func (e *HeaderMismatchError) Error() string {
+	return fmt.Sprintf("Mapping header does not match - %s", strings.Join(e.Mismatches, ", "))
+}
+
+// Verify tests whether the header matches expected values.
+// Only values known in both the headers are compared (i.e. data
+// without a header always pass). The ictools version is not compared.
+// In case of a mismatch, *HeaderMismatchError is returned.
+func (h Header) Verify(expected Header) error {
+	mismatches := make([]string, 0, 5)
+	cmpStr := func(name, v1, v2 string) {
+		if v1 != "" && v2 != "" && v1 != v2 {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s (expected %s)", name, v1, v2))
+		}
+	}
+	cmpInt := func(name string, v1, v2 int) {
+		if v1 > 0 && v2 > 0 && v1 != v2 {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %d (expected %d)", name, v1, v2))
+		}
+	}
+	cmpStr(headerKeyCorpus1, h.Corpus1, expected.Corpus1)
+	cmpStr(headerKeyCorpus2, h.Corpus2, expected.Corpus2)
+	cmpStr(headerKeyAttr, h.Attr, expected.Attr)
+	cmpInt(headerKeySize1, h.Size1, expected.Size1)
+	cmpInt(headerKeySize2, h.Size2, expected.Size2)
+	if len(mismatches) > 0 {
+		return &HeaderMismatchError{Mismatches: mismatches}
+	}
+	return nil
+}
+
+// checkPositions tests whether item positions fit into structure sizes
+func (h Header) checkPositions(item Mapping) error {
+	if item.IsError() {
+		return nil
+	}
+	if h.Size1 > 0 && item.From.Last >= h.Size1 {
+		return fmt.Errorf("Position %d exceeds structure size %d (%s)", item.From.Last, h.Size1, h.Corpus1)
+	}
+	if h.Size2 > 0 && item.To.Last >= h.Size2 {
+		return fmt.Errorf("Position %d exceeds structure size %d (%s)", item.To.Last, h.Size2, h.Corpus2)
+	}
+	return nil
+}
+
+// ----------------------------------------------
+
+// checksum calculates a CRC32 checksum of mapping items. As the
+// items are encoded independently of the data format, the checksum
+// is the same for both the text and the binary format.
+type checksum struct {
+	hash hash.Hash32
+	buff []byte
+}
+
+func (c *checksum) add(item Mapping) {
+	for i, v := range []int{item.From.First, item.From.Last, item.To.First, item.To.Last} {
+		binary.LittleEndian.PutUint32(c.buff[i*4:], uint32(int32(v)))
+	}
+	c.buff[16] = 0
+	if item.IsGap {
+		c.buff[16] = 1
+	}
+	c.hash.Write(c.buff[:17])
+	c.hash.Write([]byte(item.Meta.Status))
+	c.buff[0] = 0
+	c.hash.Write(c.buff[:1])
+	if item.Meta.HasScore {
+		binary.LittleEndian.PutUint64(c.buff, math.Float64bits(item.Meta.Score))
+		c.hash.Write(c.buff[:8])
+	}
+}
+
+func (c *checksum) String() string {
+	return fmt.Sprintf("%s%08x", checksumPrefix, c.hash.Sum32())
+}
+
+func (c *checksum) verify(expected string) error {
+	if expected != c.String() {
+		return fmt.Errorf("Checksum mismatch (expected %s, found %s)", expected, c.String())
+	}
+	return nil
+}
+
+func newChecksum() *checksum {
+	return &checksum{hash: crc32.NewIEEE(), buff: make([]byte, 17)}
+}
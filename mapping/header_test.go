@@ -0,0 +1,157 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package mapping
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(reader Reader) ([]Mapping, error) {
+	ans := make([]Mapping, 0, 10)
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			return ans, nil
+
+		} else if err != nil {
+			return ans, err
+		}
+		ans = append(ans, item)
+	}
+}
+
+func TestTextHeader(t *testing.T) {
+	header := Header{Corpus1: "intercorp_pl", Corpus2: "intercorp_cs", Attr: "s.id", Size1: 10, Size2: 21, Version: "0.2.0"}
+	data := string(convert(t, testTextMapping, FormatText, header))
+	assert.True(t, strings.HasPrefix(data, "#@ corpus1=intercorp_pl\n#@ corpus2=intercorp_cs\n#@ attr=s.id\n"))
+	assert.True(t, strings.HasPrefix(data[strings.LastIndex(data[:len(data)-1], "\n")+1:], "#@ checksum=crc32:"))
+
+	reader, err := NewReader(strings.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, header, reader.Header())
+	items, err := readAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(items))
+}
+
+func TestChecksumIsFormatIndependent(t *testing.T) {
+	text := string(convert(t, testTextMapping, FormatText, Header{}))
+	bin := convert(t, text, FormatBinary, Header{})
+	assert.Equal(t, text, string(convert(t, string(bin), FormatText, Header{})))
+}
+
+func TestTextChecksumMismatch(t *testing.T) {
+	data := string(convert(t, testTextMapping, FormatText, Header{}))
+	data = strings.Replace(data, "5,9\t4,8", "5,9\t4,9", 1)
+	reader, err := NewReader(strings.NewReader(data))
+	assert.Nil(t, err)
+	_, err = readAll(reader)
+	assert.Error(t, err)
+}
+
+func TestBinaryChecksumMismatch(t *testing.T) {
+	data := convert(t, "0\t0\n1\t1\n", FormatBinary, Header{})
+	data[len(data)-1]++
+	reader, err := NewReader(bytes.NewReader(data))
+	assert.Nil(t, err)
+	items, err := readAll(reader)
+	assert.Equal(t, 2, len(items))
+	assert.Error(t, err)
+}
+
+func TestTextWithoutChecksum(t *testing.T) {
+	reader, err := NewReader(strings.NewReader("# a comment\n0\t0\n# another one\n1\t1\n"))
+	assert.Nil(t, err)
+	assert.True(t, reader.Header().IsEmpty())
+	items, err := readAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(items))
+}
+
+func TestTextHeaderWithoutChecksum(t *testing.T) {
+	data := string(convert(t, testTextMapping, FormatText, Header{Corpus1: "intercorp_pl"}))
+	data = data[:strings.LastIndex(data[:len(data)-1], "\n")+1]
+	reader, err := NewReader(strings.NewReader(data))
+	assert.Nil(t, err)
+	items, err := readAll(reader)
+	assert.Equal(t, 8, len(items))
+	assert.Error(t, err)
+}
+
+func TestPositionExceedsSize(t *testing.T) {
+	reader, err := NewReader(strings.NewReader("#@ size1=2\n#@ size2=2\n0\t0\n1\t1,2\n"))
+	assert.Nil(t, err)
+	_, err = readAll(reader)
+	assert.IsType(t, &ParseError{}, err)
+}
+
+func TestChecksumAfterPositionExceedsSize(t *testing.T) {
+	header := Header{Size1: 2, Size2: 2}
+	for _, format := range []string{FormatText, FormatBinary} {
+		reader, err := NewReader(bytes.NewReader(convert(t, "0\t0\n1\t1,5\n", format, header)))
+		assert.Nil(t, err)
+		var parseErrors int
+		for err == nil {
+			_, err = reader.Read()
+			if _, ok := err.(*ParseError); ok {
+				parseErrors++
+				err = nil
+			}
+		}
+		assert.Equal(t, 1, parseErrors)
+		assert.Equal(t, io.EOF, err)
+	}
+}
+
+func TestHeaderValueOutsideHeader(t *testing.T) {
+	reader, err := NewReader(strings.NewReader("0\t0\n#@ corpus1=foo\n"))
+	assert.Nil(t, err)
+	_, err = readAll(reader)
+	assert.Error(t, err)
+}
+
+func TestHeaderVerify(t *testing.T) {
+	header := Header{Corpus1: "intercorp_pl", Corpus2: "intercorp_cs", Attr: "s.id", Size1: 10, Size2: 21}
+	assert.Nil(t, header.Verify(header))
+	assert.Nil(t, header.Verify(Header{}))
+	assert.Nil(t, Header{}.Verify(header))
+	assert.Nil(t, header.Verify(Header{Corpus2: "intercorp_cs", Size2: 21}))
+	assert.Error(t, header.Verify(Header{Corpus2: "intercorp_en"}))
+	assert.Error(t, header.Verify(Header{Attr: "p.id"}))
+	assert.Error(t, header.Verify(Header{Size1: 11}))
+
+	err := header.Verify(Header{Corpus1: "intercorp_en", Size2: 20})
+	if assert.IsType(t, &HeaderMismatchError{}, err) {
+		assert.Equal(t, 2, len(err.(*HeaderMismatchError).Mismatches))
+	}
+}
+
+func TestNewMappingFromStringComment(t *testing.T) {
+	_, err := NewMappingFromString("#@ corpus1=foo")
+	assert.Equal(t, ErrComment, err)
+}
+
+func TestHeaderInverted(t *testing.T) {
+	header := Header{Corpus1: "intercorp_pl", Corpus2: "intercorp_cs", Attr: "s.id", Size1: 10, Size2: 21, Version: "1.0"}
+	assert.Equal(t, Header{Corpus1: "intercorp_cs", Corpus2: "intercorp_pl", Attr: "s.id", Size1: 21, Size2: 10, Version: "1.0"},
+		header.Inverted())
+}
@@ -29,10 +29,10 @@ import (
 
 func TestIteratorFactory(t *testing.T) {
 	mList := make([]Mapping, 4)
-	mList[0] = Mapping{PosRange{1, 2}, PosRange{-1, -1}, false}
-	mList[1] = Mapping{PosRange{3, 4}, PosRange{-1, -1}, false}
-	mList[2] = Mapping{PosRange{5, 5}, PosRange{-1, -1}, false}
-	mList[3] = Mapping{PosRange{6, 6}, PosRange{0, 0}, false}
+	mList[0] = Mapping{From: PosRange{1, 2}, To: PosRange{-1, -1}, IsGap: false}
+	mList[1] = Mapping{From: PosRange{3, 4}, To: PosRange{-1, -1}, IsGap: false}
+	mList[2] = Mapping{From: PosRange{5, 5}, To: PosRange{-1, -1}, IsGap: false}
+	mList[3] = Mapping{From: PosRange{6, 6}, To: PosRange{0, 0}, IsGap: false}
 
 	itr := NewIterator(mList)
 
@@ -47,7 +47,7 @@ func TestIteratorFactoryEmptyData(t *testing.T) {
 
 func TestIteratorApplyFinishes(t *testing.T) {
 	mList := make([]Mapping, 1)
-	mList[0] = Mapping{PosRange{1, 2}, PosRange{-1, -1}, false}
+	mList[0] = Mapping{From: PosRange{1, 2}, To: PosRange{-1, -1}, IsGap: false}
 	itr := NewIterator(mList)
 
 	itr.Next()
@@ -61,7 +61,7 @@ func TestIteratorApplyFinishes(t *testing.T) {
 
 func TestIteratorCanApplyWithoutNext(t *testing.T) {
 	mList := make([]Mapping, 1)
-	mList[0] = Mapping{PosRange{1, 2}, PosRange{-1, -1}, false}
+	mList[0] = Mapping{From: PosRange{1, 2}, To: PosRange{-1, -1}, IsGap: false}
 	itr := NewIterator(mList)
 	itr.Apply(func(v Mapping) {
 		assert.Equal(t, mList[0], v)
@@ -70,10 +70,10 @@ func TestIteratorCanApplyWithoutNext(t *testing.T) {
 
 func TestIteratorHasPriorityOver(t *testing.T) {
 	mList := make([]Mapping, 4)
-	mList[0] = Mapping{PosRange{1, 2}, PosRange{-1, -1}, false}
-	mList[1] = Mapping{PosRange{3, 4}, PosRange{-1, -1}, false}
-	mList[2] = Mapping{PosRange{5, 5}, PosRange{-1, -1}, false}
-	mList[3] = Mapping{PosRange{6, 6}, PosRange{0, 0}, false}
+	mList[0] = Mapping{From: PosRange{1, 2}, To: PosRange{-1, -1}, IsGap: false}
+	mList[1] = Mapping{From: PosRange{3, 4}, To: PosRange{-1, -1}, IsGap: false}
+	mList[2] = Mapping{From: PosRange{5, 5}, To: PosRange{-1, -1}, IsGap: false}
+	mList[3] = Mapping{From: PosRange{6, 6}, To: PosRange{0, 0}, IsGap: false}
 	itr1 := NewIterator(mList)
 	itr2 := NewIterator(mList)
 
@@ -89,14 +89,14 @@ func TestIteratorHasPriorityOver(t *testing.T) {
 
 func TestMergeMappings(t *testing.T) {
 	mList1 := make([]Mapping, 4)
-	mList1[0] = Mapping{PosRange{1, 2}, PosRange{-1, -1}, false}
-	mList1[1] = Mapping{PosRange{3, 3}, PosRange{1, 1}, false}
-	mList1[2] = Mapping{PosRange{4, 5}, PosRange{4, 4}, false}
-	mList1[3] = Mapping{PosRange{6, 6}, PosRange{6, 7}, false}
+	mList1[0] = Mapping{From: PosRange{1, 2}, To: PosRange{-1, -1}, IsGap: false}
+	mList1[1] = Mapping{From: PosRange{3, 3}, To: PosRange{1, 1}, IsGap: false}
+	mList1[2] = Mapping{From: PosRange{4, 5}, To: PosRange{4, 4}, IsGap: false}
+	mList1[3] = Mapping{From: PosRange{6, 6}, To: PosRange{6, 7}, IsGap: false}
 
 	mList2 := make([]Mapping, 2)
-	mList2[0] = Mapping{PosRange{-1, -1}, PosRange{2, 3}, false}
-	mList2[1] = Mapping{PosRange{-1, -1}, PosRange{5, 5}, false}
+	mList2[0] = Mapping{From: PosRange{-1, -1}, To: PosRange{2, 3}, IsGap: false}
+	mList2[1] = Mapping{From: PosRange{-1, -1}, To: PosRange{5, 5}, IsGap: false}
 
 	i := 0
 	ans := make([]Mapping, 6)
@@ -115,12 +115,12 @@ func TestMergeMappings(t *testing.T) {
 
 func TestMergeMappingsAlternatingItems(t *testing.T) {
 	mList1 := make([]Mapping, 2)
-	mList1[0] = Mapping{PosRange{1, 1}, PosRange{1, 1}, false}
-	mList1[1] = Mapping{PosRange{2, 2}, PosRange{4, 4}, false}
+	mList1[0] = Mapping{From: PosRange{1, 1}, To: PosRange{1, 1}, IsGap: false}
+	mList1[1] = Mapping{From: PosRange{2, 2}, To: PosRange{4, 4}, IsGap: false}
 
 	mList2 := make([]Mapping, 2)
-	mList2[0] = Mapping{PosRange{-1, -1}, PosRange{2, 3}, false}
-	mList2[1] = Mapping{PosRange{-1, -1}, PosRange{5, 5}, false}
+	mList2[0] = Mapping{From: PosRange{-1, -1}, To: PosRange{2, 3}, IsGap: false}
+	mList2[1] = Mapping{From: PosRange{-1, -1}, To: PosRange{5, 5}, IsGap: false}
 
 	i := 0
 	ans := make([]Mapping, 4)
@@ -137,12 +137,12 @@ func TestMergeMappingsAlternatingItems(t *testing.T) {
 
 func TestMergeMappingsWaitingColumn(t *testing.T) {
 	mList1 := make([]Mapping, 2)
-	mList1[0] = Mapping{PosRange{1, 1}, PosRange{3, 3}, false}
-	mList1[1] = Mapping{PosRange{2, 2}, PosRange{4, 4}, false}
+	mList1[0] = Mapping{From: PosRange{1, 1}, To: PosRange{3, 3}, IsGap: false}
+	mList1[1] = Mapping{From: PosRange{2, 2}, To: PosRange{4, 4}, IsGap: false}
 
 	mList2 := make([]Mapping, 2)
-	mList2[0] = Mapping{PosRange{-1, -1}, PosRange{1, 1}, false}
-	mList2[1] = Mapping{PosRange{-1, -1}, PosRange{2, 2}, false}
+	mList2[0] = Mapping{From: PosRange{-1, -1}, To: PosRange{1, 1}, IsGap: false}
+	mList2[1] = Mapping{From: PosRange{-1, -1}, To: PosRange{2, 2}, IsGap: false}
 
 	i := 0
 	ans := make([]Mapping, 4)
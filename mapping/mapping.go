@@ -20,7 +20,10 @@
 package mapping
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/czcorpus/ictools/common"
@@ -34,6 +37,20 @@ const (
 	ErrorMark = "ERROR"
 
 	errorPositionValue = -2
+
+	gapMark = "g"
+
+	// CommentMark starts a comment line in the text format
+	CommentMark = "#"
+
+	// metadataMark starts a comment line with metadata (see Header)
+	metadataMark = "#@"
+
+	// StatusManual is a link status for manually checked alignments
+	StatusManual = "man"
+
+	// StatusAuto is a link status for automatically created alignments
+	StatusAuto = "auto"
 )
 
 // PosRange defines a range of (Manatee) structure
@@ -60,6 +77,26 @@ func (pr PosRange) String() string {
 	return fmt.Sprintf("%d,%d", pr.First, pr.Last)
 }
 
+// StructIDs provides string IDs of structures
+// (e.g. "s.id" values) by their positions.
+// It is compatible with attrib.PosAttr.
+type StructIDs interface {
+	ID2Str(ident int) string
+}
+
+// Describe converts the range into a human readable form
+// using string IDs of the first and the last structure
+// (e.g. "cs:1:3..cs:1:5"). An undefined range is described as "-".
+func (pr PosRange) Describe(ids StructIDs) string {
+	if pr.First == -1 {
+		return "-"
+	}
+	if pr.First == pr.Last {
+		return ids.ID2Str(pr.First)
+	}
+	return ids.ID2Str(pr.First) + ".." + ids.ID2Str(pr.Last)
+}
+
 // NewPosRange creates a new PosRange from
 // a list of string-encoded integers.
 // An empty string is treated as -1 (i.e. 'undefined')
@@ -82,24 +119,103 @@ func NewEmptyPosRange() PosRange {
 
 // ----------------------------------------------
 
+// LinkMeta contains optional information about an alignment link
+// as found in source data (e.g. the 'status' attribute of XCES <link>
+// or a score provided by an automatic aligner). The score is expected
+// to express confidence (i.e. the higher, the better).
+// In the numeric format, the metadata are written in an optional
+// fourth column (e.g. "man", "auto:0.87" or ":0.87").
+type LinkMeta struct {
+	Status   string
+	Score    float64
+	HasScore bool
+}
+
+// IsEmpty tests whether there is any information in the metadata
+func (lm LinkMeta) IsEmpty() bool {
+	return lm.Status == "" && !lm.HasScore
+}
+
+func (lm LinkMeta) String() string {
+	if lm.HasScore {
+		return lm.Status + ":" + strconv.FormatFloat(lm.Score, 'f', -1, 64)
+	}
+	return lm.Status
+}
+
+// Combine creates metadata for a link composed of two links (e.g. two
+// adjacent links merged during compression or two links connected via
+// a pivot language). Missing information is treated as neutral. Otherwise
+// the status is kept only if both statuses are the same (or StatusAuto is used)
+// and the lower score is used (i.e. the composed link is as reliable as
+// its weakest part).
+func (lm LinkMeta) Combine(other LinkMeta) LinkMeta {
+	if lm.IsEmpty() {
+		return other
+
+	} else if other.IsEmpty() {
+		return lm
+	}
+	ans := lm
+	if other.Status != "" && ans.Status != other.Status {
+		if ans.Status == "" {
+			ans.Status = other.Status
+
+		} else {
+			ans.Status = StatusAuto
+		}
+	}
+	if other.HasScore && (!ans.HasScore || other.Score < ans.Score) {
+		ans.Score = other.Score
+		ans.HasScore = true
+	}
+	return ans
+}
+
+// NewLinkMetaFromString parses metadata as written by LinkMeta.String()
+func NewLinkMetaFromString(src string) (LinkMeta, error) {
+	items := strings.SplitN(src, ":", 2)
+	ans := LinkMeta{Status: items[0]}
+	if len(items) == 2 {
+		score, err := strconv.ParseFloat(items[1], 64)
+		if err != nil {
+			return LinkMeta{}, fmt.Errorf("Invalid link score '%s'", items[1])
+		}
+		ans.Score = score
+		ans.HasScore = true
+	}
+	return ans, nil
+}
+
+// ----------------------------------------------
+
 // Mapping represents a mapping between two structures from aligned corpora.
 // These mappings are in general M:N (which is why we use PosRange internally
 // here).
 // Besides positions 0,...,N the code here uses also -1 for undefined mapping
 // and -2 for an error record (but the error record is exported into a special
 // string when creating the outout).
+// Optional link metadata (Meta) are exported only if non-empty.
 type Mapping struct {
 	From  PosRange
 	To    PosRange
 	IsGap bool
+	Meta  LinkMeta
 }
 
 func (m Mapping) String() string {
+	if m.From.First == errorPositionValue && !m.IsGap {
+		return fmt.Sprintf(ErrorMark)
+	}
+	var flags string
 	if m.IsGap {
-		return fmt.Sprintf("%s\t%s\tg", m.From, m.To)
+		flags = gapMark
+	}
+	if !m.Meta.IsEmpty() {
+		return fmt.Sprintf("%s\t%s\t%s\t%s", m.From, m.To, flags, m.Meta)
 
-	} else if m.From.First == errorPositionValue {
-		return fmt.Sprintf(ErrorMark)
+	} else if m.IsGap {
+		return fmt.Sprintf("%s\t%s\t%s", m.From, m.To, flags)
 	}
 	return fmt.Sprintf("%s\t%s", m.From, m.To)
 }
@@ -108,14 +224,19 @@ func (m *Mapping) IsEmpty() bool {
 	return m.From.First == -1 && m.To.First == -1
 }
 
+// IsError tests whether the mapping is an error record
+// (see NewErrorMapping)
+func (m *Mapping) IsError() bool {
+	return m.From.First == errorPositionValue && !m.IsGap
+}
+
 // NewMapping creates a new instance of Mapping.
 // The arguments can be understood as follows:
 // from1,from2[TAB]to1,to2
 func NewMapping(from1 int, from2 int, to1 int, to2 int) Mapping {
 	return Mapping{
-		PosRange{from1, from2},
-		PosRange{to1, to2},
-		false,
+		From: PosRange{from1, from2},
+		To:   PosRange{to1, to2},
 	}
 }
 
@@ -125,19 +246,37 @@ func NewMapping(from1 int, from2 int, to1 int, to2 int) Mapping {
 // from1,from2[TAB]to1,to2
 func NewGapMapping(from1 int, from2 int, to1 int, to2 int) Mapping {
 	return Mapping{
-		PosRange{from1, from2},
-		PosRange{to1, to2},
-		true,
+		From:  PosRange{from1, from2},
+		To:    PosRange{to1, to2},
+		IsGap: true,
 	}
 }
 
+// ErrComment is returned by NewMappingFromString
+// in case of a comment line (see IsComment)
+var ErrComment = errors.New("Comment line")
+
+// IsComment tests whether a line of the text format
+// is a comment (such lines contain no mapping)
+func IsComment(line string) bool {
+	return strings.HasPrefix(line, CommentMark)
+}
+
 // NewMappingFromString creates a new Mapping instance
 // from a two-column numeric source code line used as
-// an intermediate format.
+// an intermediate format. The two columns may be followed
+// by a gap flag column and a link metadata column.
+// For comment lines, ErrComment is returned.
 func NewMappingFromString(src string) (Mapping, error) {
+	if IsComment(src) {
+		return Mapping{}, ErrComment
+	}
 	items := strings.Split(src, "\t")
 	if len(items) < 2 {
 		return Mapping{}, fmt.Errorf("No TAB separated data found")
+
+	} else if len(items) > 4 {
+		return Mapping{}, fmt.Errorf("Too many columns found")
 	}
 	l1t := strings.Split(items[0], ",")
 	l2t := strings.Split(items[1], ",")
@@ -149,7 +288,15 @@ func NewMappingFromString(src string) (Mapping, error) {
 	if err2 != nil {
 		return Mapping{}, err2
 	}
-	return Mapping{r1, r2, len(items) == 3}, nil
+	ans := Mapping{From: r1, To: r2, IsGap: len(items) > 2 && items[2] == gapMark}
+	if len(items) == 4 {
+		meta, err := NewLinkMetaFromString(items[3])
+		if err != nil {
+			return Mapping{}, err
+		}
+		ans.Meta = meta
+	}
+	return ans, nil
 }
 
 // NewErrorMapping creates a mapping with all the
@@ -231,3 +378,37 @@ func MergeMappings(mainMapping []Mapping, mapFromEmpty []Mapping, onItem func(it
 		}
 	}
 }
+
+// Invert swaps sides of all the items of an alignment (e.g. to obtain
+// a cs-en alignment from an en-cs one) and passes them to onItem
+// ordered by the new left side in the same way transalign orders
+// its output (see MergeMappings). Gap flags and link metadata are
+// preserved, error records and empty items are skipped.
+// Please note that the function does not merge subsequent gap items
+// which become adjacent after the inversion (see calign.CompressFromChan).
+func Invert(items []Mapping, onItem func(item Mapping)) {
+	main := make([]Mapping, 0, len(items))
+	fromEmpty := make([]Mapping, 0, len(items)/10)
+	for _, item := range items {
+		if item.IsError() || item.IsEmpty() {
+			continue
+		}
+		inv := item
+		inv.From, inv.To = item.To, item.From
+		if inv.From.First == -1 {
+			fromEmpty = append(fromEmpty, inv)
+
+		} else {
+			main = append(main, inv)
+		}
+	}
+	sort.Stable(SortableMapping(main))
+	sort.Stable(SortableMapping(fromEmpty))
+	if len(main) == 0 {
+		for _, item := range fromEmpty {
+			onItem(item)
+		}
+		return
+	}
+	MergeMappings(main, fromEmpty, onItem)
+}
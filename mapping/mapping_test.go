@@ -52,6 +52,19 @@ func TestPosRangeString(t *testing.T) {
 	assert.Equal(t, "-1", p2.String())
 }
 
+type structIDs []string
+
+func (ids structIDs) ID2Str(ident int) string {
+	return ids[ident]
+}
+
+func TestPosRangeDescribe(t *testing.T) {
+	ids := structIDs{"s0", "s1", "s2"}
+	assert.Equal(t, "s1", PosRange{1, 1}.Describe(ids))
+	assert.Equal(t, "s0..s2", PosRange{0, 2}.Describe(ids))
+	assert.Equal(t, "-", PosRange{-1, -1}.Describe(ids))
+}
+
 // ----- PosRange factories
 
 func TestNewPosRangeOK(t *testing.T) {
@@ -102,7 +115,7 @@ func TestNewPosRangeGibberishString(t *testing.T) {
 // are generated by PosRange which is tested above
 
 func TestMappingString(t *testing.T) {
-	m := Mapping{PosRange{1, 2}, PosRange{3, 4}, false}
+	m := Mapping{From: PosRange{1, 2}, To: PosRange{3, 4}, IsGap: false}
 	assert.Equal(t, "1,2\t3,4", m.String())
 }
 
@@ -161,10 +174,10 @@ func TestNewMappingFromGibberishString(t *testing.T) {
 
 func TestSortableMappingTypeA(t *testing.T) {
 	mList := make([]Mapping, 4)
-	mList[0] = Mapping{PosRange{1, 2}, PosRange{1, 3}, false}
-	mList[1] = Mapping{PosRange{3, 4}, PosRange{4, 4}, false}
-	mList[2] = Mapping{PosRange{5, 5}, PosRange{-1, -1}, false}
-	mList[3] = Mapping{PosRange{6, 6}, PosRange{-1, -1}, false}
+	mList[0] = Mapping{From: PosRange{1, 2}, To: PosRange{1, 3}, IsGap: false}
+	mList[1] = Mapping{From: PosRange{3, 4}, To: PosRange{4, 4}, IsGap: false}
+	mList[2] = Mapping{From: PosRange{5, 5}, To: PosRange{-1, -1}, IsGap: false}
+	mList[3] = Mapping{From: PosRange{6, 6}, To: PosRange{-1, -1}, IsGap: false}
 	smList := SortableMapping(mList)
 
 	assert.True(t, smList.Less(0, 1))
@@ -175,8 +188,8 @@ func TestSortableMappingTypeA(t *testing.T) {
 
 func TestSortableMappingTypeB(t *testing.T) {
 	mList := make([]Mapping, 3)
-	mList[0] = Mapping{PosRange{-1, -1}, PosRange{1, 3}, false}
-	mList[1] = Mapping{PosRange{-1, -1}, PosRange{4, 4}, false}
+	mList[0] = Mapping{From: PosRange{-1, -1}, To: PosRange{1, 3}, IsGap: false}
+	mList[1] = Mapping{From: PosRange{-1, -1}, To: PosRange{4, 4}, IsGap: false}
 	smList := SortableMapping(mList)
 
 	assert.True(t, smList.Less(0, 1))
@@ -184,8 +197,8 @@ func TestSortableMappingTypeB(t *testing.T) {
 
 func TestSortableMappingTypeInvalid(t *testing.T) {
 	mList := make([]Mapping, 2)
-	mList[0] = Mapping{PosRange{-1, -1}, PosRange{4, 4}, false}
-	mList[1] = Mapping{PosRange{5, 5}, PosRange{-1, -1}, false}
+	mList[0] = Mapping{From: PosRange{-1, -1}, To: PosRange{4, 4}, IsGap: false}
+	mList[1] = Mapping{From: PosRange{5, 5}, To: PosRange{-1, -1}, IsGap: false}
 	smList := SortableMapping(mList)
 
 	assert.Panics(t, func() {
@@ -195,11 +208,11 @@ func TestSortableMappingTypeInvalid(t *testing.T) {
 
 func TestSortableMappingSwap(t *testing.T) {
 	mList := make([]Mapping, 3)
-	m0 := Mapping{PosRange{1, 2}, PosRange{1, 3}, false}
+	m0 := Mapping{From: PosRange{1, 2}, To: PosRange{1, 3}, IsGap: false}
 	mList[0] = m0
-	m1 := Mapping{PosRange{3, 4}, PosRange{4, 4}, false}
+	m1 := Mapping{From: PosRange{3, 4}, To: PosRange{4, 4}, IsGap: false}
 	mList[1] = m1
-	m2 := Mapping{PosRange{-1, -1}, PosRange{5, 5}, false}
+	m2 := Mapping{From: PosRange{-1, -1}, To: PosRange{5, 5}, IsGap: false}
 	mList[2] = m2
 	smList := SortableMapping(mList)
 
@@ -214,14 +227,14 @@ func TestSortableMappingSwap(t *testing.T) {
 
 func TestSortableMappingSortingLen(t *testing.T) {
 	mList := make([]Mapping, 3)
-	mList[0] = Mapping{PosRange{1, 2}, PosRange{-1, -1}, false}
-	mList[1] = Mapping{PosRange{3, 4}, PosRange{-1, -1}, false}
-	mList[2] = Mapping{PosRange{5, 5}, PosRange{-1, -1}, false}
+	mList[0] = Mapping{From: PosRange{1, 2}, To: PosRange{-1, -1}, IsGap: false}
+	mList[1] = Mapping{From: PosRange{3, 4}, To: PosRange{-1, -1}, IsGap: false}
+	mList[2] = Mapping{From: PosRange{5, 5}, To: PosRange{-1, -1}, IsGap: false}
 	smList := SortableMapping(mList)
 
 	assert.Equal(t, 3, smList.Len())
 
-	smList = append(smList, Mapping{PosRange{6, 6}, PosRange{0, 0}, false})
+	smList = append(smList, Mapping{From: PosRange{6, 6}, To: PosRange{0, 0}, IsGap: false})
 
 	assert.Equal(t, 4, smList.Len())
 }
@@ -231,3 +244,97 @@ func TestNewEmptyPosRange(t *testing.T) {
 	assert.Equal(t, -1, p.First)
 	assert.Equal(t, -1, p.Last)
 }
+
+// -------- LinkMeta
+
+func TestLinkMetaString(t *testing.T) {
+	assert.Equal(t, "man", LinkMeta{Status: StatusManual}.String())
+	assert.Equal(t, "auto:0.25", LinkMeta{Status: StatusAuto, Score: 0.25, HasScore: true}.String())
+	assert.Equal(t, ":1", LinkMeta{Score: 1, HasScore: true}.String())
+	assert.True(t, LinkMeta{}.IsEmpty())
+}
+
+func TestNewLinkMetaFromString(t *testing.T) {
+	m, err := NewLinkMetaFromString("auto:0.25")
+	assert.Nil(t, err)
+	assert.Equal(t, LinkMeta{Status: StatusAuto, Score: 0.25, HasScore: true}, m)
+	m, err = NewLinkMetaFromString("man")
+	assert.Nil(t, err)
+	assert.Equal(t, LinkMeta{Status: StatusManual}, m)
+	_, err = NewLinkMetaFromString("auto:foo")
+	assert.Error(t, err)
+}
+
+func TestLinkMetaCombine(t *testing.T) {
+	man := LinkMeta{Status: StatusManual}
+	auto := LinkMeta{Status: StatusAuto, Score: 0.7, HasScore: true}
+	assert.Equal(t, man, man.Combine(LinkMeta{}))
+	assert.Equal(t, man, LinkMeta{}.Combine(man))
+	assert.Equal(t, man, man.Combine(man))
+	assert.Equal(t, auto, man.Combine(auto))
+	assert.Equal(t, auto, auto.Combine(man))
+	assert.Equal(
+		t,
+		LinkMeta{Status: StatusAuto, Score: 0.5, HasScore: true},
+		auto.Combine(LinkMeta{Status: StatusAuto, Score: 0.5, HasScore: true}),
+	)
+}
+
+func TestMappingStringWithMeta(t *testing.T) {
+	m := NewMapping(1, 2, 3, 3)
+	m.Meta = LinkMeta{Status: StatusManual}
+	assert.Equal(t, "1,2\t3\t\tman", m.String())
+	m.IsGap = true
+	assert.Equal(t, "1,2\t3\tg\tman", m.String())
+}
+
+func TestNewMappingFromStringWithMeta(t *testing.T) {
+	m, err := NewMappingFromString("1,2\t3\t\tauto:0.5")
+	assert.Nil(t, err)
+	assert.Equal(t, NewMapping(1, 2, 3, 3), Mapping{From: m.From, To: m.To, IsGap: m.IsGap})
+	assert.Equal(t, LinkMeta{Status: StatusAuto, Score: 0.5, HasScore: true}, m.Meta)
+
+	m2, err := NewMappingFromString("-1\t3\tg\tman")
+	assert.Nil(t, err)
+	assert.True(t, m2.IsGap)
+	assert.Equal(t, StatusManual, m2.Meta.Status)
+
+	_, err = NewMappingFromString("1\t3\tg\tman\tfoo")
+	assert.Error(t, err)
+}
+
+func TestNewMappingFromStringGap(t *testing.T) {
+	m, err := NewMappingFromString("-1\t3,4\tg")
+	assert.Nil(t, err)
+	assert.Equal(t, NewGapMapping(-1, -1, 3, 4), m)
+}
+
+func TestInvert(t *testing.T) {
+	items := []Mapping{
+		NewMapping(0, 0, 0, 0),
+		NewGapMapping(-1, -1, 1, 2),
+		NewMapping(1, 1, 3, 4),
+		NewMapping(2, 2, -1, -1),
+		NewErrorMapping(),
+		NewMapping(3, 4, 5, 5),
+	}
+	ans := make([]Mapping, 0, 6)
+	Invert(items, func(item Mapping) {
+		ans = append(ans, item)
+	})
+	assert.Equal(t, []Mapping{
+		NewMapping(0, 0, 0, 0),
+		NewGapMapping(1, 2, -1, -1),
+		NewMapping(3, 4, 1, 1),
+		NewMapping(-1, -1, 2, 2),
+		NewMapping(5, 5, 3, 4),
+	}, ans)
+}
+
+func TestInvertOnlyEmptyLeft(t *testing.T) {
+	ans := make([]Mapping, 0, 2)
+	Invert([]Mapping{NewMapping(1, 1, -1, -1), NewMapping(0, 0, -1, -1)}, func(item Mapping) {
+		ans = append(ans, item)
+	})
+	assert.Equal(t, []Mapping{NewMapping(-1, -1, 0, 0), NewMapping(-1, -1, 1, 1)}, ans)
+}
@@ -0,0 +1,151 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package output provides writing of results to files which
+// appear at their final location only once they are complete.
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// FileMode is a permission mode of created files
+	FileMode = 0644
+
+	bufferSize = 256 * 1024
+)
+
+// compressionOf returns a compression method
+// determined by a file extension ("" = none)
+func compressionOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".gz":
+		return "gzip"
+	case ".zst", ".zstd":
+		return "zstd"
+	}
+	return ""
+}
+
+// File is a buffered output which is written to a temporary file
+// first. The temporary file (created in the same directory) replaces
+// the target file only once Commit is called so a failed run never
+// leaves a truncated result looking like a valid one.
+type File struct {
+	path       string
+	tmp        *os.File
+	buff       *bufio.Writer
+	compressor io.WriteCloser
+	writer     io.Writer
+}
+
+// Path returns the target path ("" for stdout)
+func (f *File) Path() string {
+	return f.path
+}
+
+func (f *File) Write(p []byte) (int, error) {
+	return f.writer.Write(p)
+}
+
+// Commit writes all the buffered data and moves the temporary
+// file to the target path. In case of stdout, the data are just
+// flushed.
+func (f *File) Commit() error {
+	if f.compressor != nil {
+		if err := f.compressor.Close(); err != nil {
+			f.Abort()
+			return err
+		}
+	}
+	if err := f.buff.Flush(); err != nil {
+		f.Abort()
+		return err
+	}
+	if f.tmp == nil {
+		return nil
+	}
+	if err := f.tmp.Close(); err != nil {
+		os.Remove(f.tmp.Name())
+		return err
+	}
+	if err := os.Chmod(f.tmp.Name(), FileMode); err != nil {
+		os.Remove(f.tmp.Name())
+		return err
+	}
+	if err := os.Rename(f.tmp.Name(), f.path); err != nil {
+		os.Remove(f.tmp.Name())
+		return err
+	}
+	return nil
+}
+
+// Abort removes the temporary file leaving the target path
+// untouched. In case of stdout, which cannot be taken back,
+// buffered data are just flushed.
+func (f *File) Abort() error {
+	if f.tmp == nil {
+		return f.buff.Flush()
+	}
+	if f.compressor != nil {
+		f.compressor.Close()
+	}
+	f.tmp.Close()
+	if err := os.Remove(f.tmp.Name()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Create creates a new output for a specified path. An empty path
+// means stdout. In case the path ends with .gz, data are compressed
+// using gzip, in case of .zst (.zstd) using zstd.
+func Create(path string) (*File, error) {
+	if path == "" {
+		buff := bufio.NewWriterSize(os.Stdout, bufferSize)
+		return &File{buff: buff, writer: buff}, nil
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	ans := &File{path: path, tmp: tmp}
+	ans.buff = bufio.NewWriterSize(tmp, bufferSize)
+	ans.writer = ans.buff
+	switch compressionOf(path) {
+	case "gzip":
+		ans.compressor = gzip.NewWriter(ans.buff)
+	case "zstd":
+		ans.compressor, err = zstd.NewWriter(ans.buff)
+		if err != nil {
+			ans.Abort()
+			return nil, err
+		}
+	}
+	if ans.compressor != nil {
+		ans.writer = ans.compressor
+	}
+	return ans, nil
+}
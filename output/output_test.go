@@ -0,0 +1,117 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package output
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+)
+
+func createTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "ictools-output")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func listDir(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ans := make([]string, len(infos))
+	for i, info := range infos {
+		ans[i] = info.Name()
+	}
+	return ans
+}
+
+func TestCommit(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.txt")
+	out, err := Create(path)
+	assert.Nil(t, err)
+	out.Write([]byte("0\t0\n"))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+	assert.Nil(t, out.Commit())
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "0\t0\n", string(data))
+	assert.Equal(t, []string{"out.txt"}, listDir(t, dir))
+}
+
+func TestAbortKeepsOriginal(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.txt")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("original"), FileMode))
+	out, err := Create(path)
+	assert.Nil(t, err)
+	out.Write([]byte("truncated"))
+	assert.Nil(t, out.Abort())
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	assert.Equal(t, "original", string(data))
+	assert.Equal(t, []string{"out.txt"}, listDir(t, dir))
+}
+
+func TestGzip(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.txt.gz")
+	out, err := Create(path)
+	assert.Nil(t, err)
+	out.Write([]byte("0\t0\n"))
+	assert.Nil(t, out.Commit())
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+	defer file.Close()
+	rd, err := gzip.NewReader(file)
+	assert.Nil(t, err)
+	data, err := ioutil.ReadAll(rd)
+	assert.Nil(t, err)
+	assert.Equal(t, "0\t0\n", string(data))
+}
+
+func TestZstd(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "out.txt.zst")
+	out, err := Create(path)
+	assert.Nil(t, err)
+	out.Write([]byte("0\t0\n"))
+	assert.Nil(t, out.Commit())
+	file, err := os.Open(path)
+	assert.Nil(t, err)
+	defer file.Close()
+	rd, err := zstd.NewReader(file)
+	assert.Nil(t, err)
+	defer rd.Close()
+	data, err := ioutil.ReadAll(rd)
+	assert.Nil(t, err)
+	assert.Equal(t, "0\t0\n", string(data))
+	assert.Equal(t, []string{"out.txt.zst"}, listDir(t, dir))
+}
@@ -0,0 +1,201 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package pipeline allows assembling processing of alignment items
+// from reusable stages. Each stage runs in its own goroutine and stages
+// are connected by bounded channels of item batches so a slow stage
+// (typically writing of the result) slows down the preceding ones
+// instead of buffering the whole data. The first error returned by any
+// part of a pipeline stops the whole pipeline.
+package pipeline
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+const (
+	// BatchSize is a number of items sent between stages at once
+	BatchSize = 5000
+
+	// chanCapacity is a number of batches a stage can produce
+	// before it has to wait for the next stage
+	chanCapacity = 5
+)
+
+// Source produces items passed to the first stage of a pipeline.
+// Once the context is cancelled, emitted items are dropped so
+// a long running source should check the context and return
+// its error.
+type Source func(ctx context.Context, emit func(item mapping.Mapping)) error
+
+// Stage reads all the items from the input channel and passes
+// (possibly modified, removed or added) items to the next stage.
+type Stage func(ctx context.Context, in chan []mapping.Mapping, emit func(item mapping.Mapping)) error
+
+// Sink consumes items produced by the last stage of a pipeline
+type Sink func(item mapping.Mapping) error
+
+// ContextReader returns a reader which ends reading with
+// the context error once the context is cancelled
+func ContextReader(ctx context.Context, src io.Reader) io.Reader {
+	return &contextReader{ctx: ctx, src: src}
+}
+
+type contextReader struct {
+	ctx context.Context
+	src io.Reader
+}
+
+func (cr *contextReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.src.Read(p)
+}
+
+// ----------------------------------------------
+
+// batcher collects emitted items and sends them
+// to a channel in batches
+type batcher struct {
+	ctx  context.Context
+	ch   chan []mapping.Mapping
+	buff []mapping.Mapping
+}
+
+func (b *batcher) send() {
+	select {
+	case b.ch <- b.buff:
+	case <-b.ctx.Done():
+	}
+	b.buff = make([]mapping.Mapping, 0, BatchSize)
+}
+
+func (b *batcher) emit(item mapping.Mapping) {
+	if b.ctx.Err() != nil {
+		return
+	}
+	b.buff = append(b.buff, item)
+	if len(b.buff) == BatchSize {
+		b.send()
+	}
+}
+
+// close sends remaining items and closes the channel
+func (b *batcher) close() {
+	if len(b.buff) > 0 && b.ctx.Err() == nil {
+		b.send()
+	}
+	close(b.ch)
+}
+
+func newBatcher(ctx context.Context) *batcher {
+	return &batcher{
+		ctx:  ctx,
+		ch:   make(chan []mapping.Mapping, chanCapacity),
+		buff: make([]mapping.Mapping, 0, BatchSize),
+	}
+}
+
+// ----------------------------------------------
+
+// Pipeline is a sequence of stages processing items of a source
+type Pipeline struct {
+	source Source
+	stages []Stage
+}
+
+// Then appends a stage to the pipeline
+func (p *Pipeline) Then(stage Stage) *Pipeline {
+	p.stages = append(p.stages, stage)
+	return p
+}
+
+// Run runs the source and all the stages and passes the resulting
+// items to the sink (in the calling goroutine). Once any part of the
+// pipeline returns an error, the remaining parts are cancelled and
+// the error is returned. In case ctx is cancelled, its error
+// is returned.
+func (p *Pipeline) Run(ctx context.Context, sink Sink) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var firstErr error
+	var mu sync.Mutex
+	setErr := func(err error) {
+		mu.Lock()
+		if firstErr == nil {
+			firstErr = err
+		}
+		mu.Unlock()
+		cancel()
+	}
+	var wg sync.WaitGroup
+
+	out := newBatcher(runCtx)
+	wg.Add(1)
+	go func(out *batcher) {
+		defer wg.Done()
+		if err := p.source(runCtx, out.emit); err != nil {
+			setErr(err)
+		}
+		out.close()
+	}(out)
+
+	for _, stage := range p.stages {
+		in := out
+		out = newBatcher(runCtx)
+		wg.Add(1)
+		go func(stage Stage, in, out *batcher) {
+			defer wg.Done()
+			if err := stage(runCtx, in.ch, out.emit); err != nil {
+				setErr(err)
+			}
+			for range in.ch {
+				// the stage ended prematurely so the preceding
+				// one must not wait for it
+			}
+			out.close()
+		}(stage, in, out)
+	}
+
+	for buff := range out.ch {
+		for _, item := range buff {
+			if runCtx.Err() != nil {
+				break
+			}
+			if err := sink(item); err != nil {
+				setErr(err)
+			}
+		}
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return firstErr
+}
+
+// New creates a new pipeline reading items from a source
+func New(source Source) *Pipeline {
+	return &Pipeline{
+		source: source,
+		stages: make([]Stage, 0, 5),
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/czcorpus/ictools/fixgaps"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func runCollect(ctx context.Context, p *Pipeline) ([]mapping.Mapping, error) {
+	ans := make([]mapping.Mapping, 0, 10)
+	err := p.Run(ctx, func(item mapping.Mapping) error {
+		ans = append(ans, item)
+		return nil
+	})
+	return ans, err
+}
+
+// infiniteSource emits items until the context is cancelled
+func infiniteSource(ctx context.Context, emit func(item mapping.Mapping)) error {
+	for i := 0; ; i++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		emit(mapping.NewMapping(i, i, i, i))
+	}
+}
+
+func TestRunPreservesOrder(t *testing.T) {
+	items := make([]mapping.Mapping, 3*BatchSize+7)
+	for i := range items {
+		items[i] = mapping.NewMapping(i, i, i, i)
+	}
+	ans, err := runCollect(context.Background(), New(Items(items)).
+		Then(Filter(func(item mapping.Mapping) bool {
+			return item.From.First%2 == 0
+		})).
+		Then(Transform(func(item mapping.Mapping) mapping.Mapping {
+			item.To.Last++
+			return item
+		})))
+	assert.Nil(t, err)
+	assert.Equal(t, (len(items)+1)/2, len(ans))
+	for i, item := range ans {
+		assert.Equal(t, mapping.NewMapping(2*i, 2*i, 2*i, 2*i+1), item)
+	}
+}
+
+func TestRunFixGapsCompress(t *testing.T) {
+	items := []mapping.Mapping{
+		mapping.NewMapping(0, 0, 0, 0),
+		mapping.NewMapping(3, 3, 1, 1),
+	}
+	ans, err := runCollect(context.Background(), New(Items(items)).
+		Then(FixGaps(true, 5, 2, nil)).
+		Then(Compress(true)))
+	assert.Nil(t, err)
+	assert.Equal(t, []mapping.Mapping{
+		mapping.NewMapping(0, 0, 0, 0),
+		mapping.NewGapMapping(1, 2, -1, -1),
+		mapping.NewMapping(3, 3, 1, 1),
+		mapping.NewGapMapping(4, 4, -1, -1),
+	}, ans)
+}
+
+func TestRunFixGapsReportsOverlap(t *testing.T) {
+	items := []mapping.Mapping{
+		mapping.NewMapping(0, 1, 0, 1),
+		mapping.NewMapping(1, 1, 2, 2),
+	}
+	overlaps := make([]*fixgaps.FixGapsError, 0, 1)
+	ans, err := runCollect(context.Background(), New(Items(items)).
+		Then(FixGaps(true, 2, 3, func(err *fixgaps.FixGapsError) {
+			overlaps = append(overlaps, err)
+		})))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(overlaps))
+	assert.True(t, ans[1].IsError())
+}
+
+func TestRunSinkErrorStopsSource(t *testing.T) {
+	sinkErr := errors.New("sink failed")
+	numWritten := 0
+	err := New(infiniteSource).Then(Compress(true)).Run(context.Background(), func(item mapping.Mapping) error {
+		numWritten++
+		if numWritten == 10 {
+			return sinkErr
+		}
+		return nil
+	})
+	assert.Equal(t, sinkErr, err)
+	assert.Equal(t, 10, numWritten)
+}
+
+func TestRunStageErrorStopsPipeline(t *testing.T) {
+	stageErr := errors.New("stage failed")
+	_, err := runCollect(context.Background(), New(infiniteSource).
+		Then(func(ctx context.Context, in chan []mapping.Mapping, emit func(item mapping.Mapping)) error {
+			<-in
+			return stageErr
+		}))
+	assert.Equal(t, stageErr, err)
+}
+
+func TestRunCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	numWritten := 0
+	err := New(infiniteSource).Run(ctx, func(item mapping.Mapping) error {
+		numWritten++
+		if numWritten == 10 {
+			cancel()
+		}
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 10, numWritten)
+}
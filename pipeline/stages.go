@@ -0,0 +1,142 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package pipeline
+
+import (
+	"context"
+	"io"
+
+	"github.com/czcorpus/ictools/calign"
+	"github.com/czcorpus/ictools/fixgaps"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/czcorpus/ictools/transalign"
+)
+
+// Parse creates a source transforming alignment data of a supported
+// input format (see calign.NewInputReader) into numeric mappings
+func Parse(processor calign.InputReader, src io.Reader, sourceName string, bufferSize int) Source {
+	return func(ctx context.Context, emit func(item mapping.Mapping)) error {
+		return processor.Process(ContextReader(ctx, src), sourceName, bufferSize, func(item mapping.Mapping, i int) {
+			emit(item)
+		})
+	}
+}
+
+// Items creates a source of already available items
+func Items(items []mapping.Mapping) Source {
+	return func(ctx context.Context, emit func(item mapping.Mapping)) error {
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			emit(item)
+		}
+		return nil
+	}
+}
+
+// Transalign creates a source of items produced by transalign.RunContext
+func Transalign(pm1, pm2 *transalign.PivotMapping) Source {
+	return func(ctx context.Context, emit func(item mapping.Mapping)) error {
+		return transalign.RunContext(ctx, pm1, pm2, emit)
+	}
+}
+
+// TransalignStreaming creates a source of items produced by
+// transalign.RunStreaming. The streams should read their data
+// via ContextReader to stop once the pipeline is cancelled.
+func TransalignStreaming(ps1, ps2 *transalign.PivotStream) Source {
+	return func(ctx context.Context, emit func(item mapping.Mapping)) error {
+		return transalign.RunStreaming(ps1, ps2, emit)
+	}
+}
+
+// Func creates a source from a function producing items
+// (e.g. a transalign run with custom arguments)
+func Func(run func(onItem func(item mapping.Mapping)) error) Source {
+	return func(ctx context.Context, emit func(item mapping.Mapping)) error {
+		return run(emit)
+	}
+}
+
+// ----------------------------------------------
+
+// FixGaps creates a stage filling in unaligned structures
+// (see fixgaps.FromChan). Items overlapping already covered
+// ranges are replaced by error mappings and reported via onError
+// (optional).
+func FixGaps(startFromZero bool, struct1Size int, struct2Size int, onError func(err *fixgaps.FixGapsError)) Stage {
+	return func(ctx context.Context, in chan []mapping.Mapping, emit func(item mapping.Mapping)) error {
+		fixgaps.FromChan(in, startFromZero, struct1Size, struct2Size, func(item mapping.Mapping, err *fixgaps.FixGapsError) {
+			if err != nil {
+				if onError != nil {
+					onError(err)
+				}
+				emit(mapping.NewErrorMapping())
+
+			} else {
+				emit(item)
+			}
+		})
+		return nil
+	}
+}
+
+// Compress creates a stage merging subsequent one-sided items
+// (see calign.CompressFromChan)
+func Compress(gapsOnly bool) Stage {
+	return func(ctx context.Context, in chan []mapping.Mapping, emit func(item mapping.Mapping)) error {
+		calign.CompressFromChan(in, gapsOnly, emit)
+		return nil
+	}
+}
+
+// Filter creates a stage passing only items matching a predicate
+func Filter(match func(item mapping.Mapping) bool) Stage {
+	return func(ctx context.Context, in chan []mapping.Mapping, emit func(item mapping.Mapping)) error {
+		for buff := range in {
+			for _, item := range buff {
+				if match(item) {
+					emit(item)
+				}
+			}
+		}
+		return nil
+	}
+}
+
+// Transform creates a stage replacing each item by a result of fn
+func Transform(fn func(item mapping.Mapping) mapping.Mapping) Stage {
+	return func(ctx context.Context, in chan []mapping.Mapping, emit func(item mapping.Mapping)) error {
+		for buff := range in {
+			for _, item := range buff {
+				emit(fn(item))
+			}
+		}
+		return nil
+	}
+}
+
+// ----------------------------------------------
+
+// Write creates a sink writing items using a mapping writer. The writer
+// is not closed as this should happen only if the whole pipeline succeeds.
+func Write(writer mapping.Writer) Sink {
+	return func(item mapping.Mapping) error {
+		return writer.Write(item)
+	}
+}
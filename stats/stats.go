@@ -0,0 +1,341 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package stats provides functions to calculate statistics
+// of a numeric alignment.
+package stats
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/export"
+	"github.com/czcorpus/ictools/mapping"
+)
+
+const (
+	// NumLongestRanges is a number of longest ranges
+	// listed in the statistics
+	NumLongestRanges = 10
+
+	arityNM = "n-m"
+)
+
+var (
+	arityTypes = []string{"1-1", "1-2", "2-1", "0-1", "1-0", arityNM}
+)
+
+// arityType returns an arity type of a two-sided link
+func arityType(size1, size2 int) string {
+	switch {
+	case size1 == 1 && size2 == 1:
+		return "1-1"
+	case size1 == 1 && size2 == 2:
+		return "1-2"
+	case size1 == 2 && size2 == 1:
+		return "2-1"
+	}
+	return arityNM
+}
+
+func newArity() map[string]int {
+	ans := make(map[string]int)
+	for _, k := range arityTypes {
+		ans[k] = 0
+	}
+	return ans
+}
+
+func percent(v, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(v) / float64(total) * 100
+}
+
+func rangeSize(rng mapping.PosRange) int {
+	if rng.First == -1 {
+		return 0
+	}
+	return rng.Last - rng.First + 1
+}
+
+// LongRange describes one of the longest aligned ranges
+type LongRange struct {
+	Line  int    `json:"line"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Size1 int    `json:"size1"`
+	Size2 int    `json:"size2"`
+}
+
+// DocStats contains statistics of a single document
+type DocStats struct {
+	ID         string         `json:"id"`
+	Arity      map[string]int `json:"arity"`
+	Aligned1   int            `json:"aligned1"`
+	Aligned2   int            `json:"aligned2"`
+	Unaligned1 int            `json:"unaligned1"`
+	Unaligned2 int            `json:"unaligned2"`
+	Coverage1  float64        `json:"coverage1"`
+	Coverage2  float64        `json:"coverage2"`
+}
+
+// Stats contains statistics of a numeric alignment.
+// Arity of one-sided items is counted per structure (i.e. a compressed
+// range [-1, a..b] represents b-a+1 0-1 links). Gap rows are counted
+// as 0-1 (1-0) links too. Coverage is a percentage of structures
+// aligned to something in the other language.
+type Stats struct {
+	Corpus1   string         `json:"corpus1"`
+	Corpus2   string         `json:"corpus2"`
+	Size1     int            `json:"size1"`
+	Size2     int            `json:"size2"`
+	NumItems  int            `json:"numItems"`
+	ErrorRows int            `json:"errorRows"`
+	Arity     map[string]int `json:"arity"`
+	Aligned1  int            `json:"aligned1"`
+	Aligned2  int            `json:"aligned2"`
+	Coverage1 float64        `json:"coverage1"`
+	Coverage2 float64        `json:"coverage2"`
+	GapRows   int            `json:"gapRows"`
+	GapSize   int            `json:"gapSize"`
+	Longest   []*LongRange   `json:"longest"`
+	Documents []*DocStats    `json:"documents,omitempty"`
+}
+
+// WriteJSON writes the statistics in the JSON format
+func (st *Stats) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(st)
+}
+
+// WriteText writes the statistics in a human readable form
+func (st *Stats) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	printArity := func(arity map[string]int, indent string) {
+		total := 0
+		for _, v := range arity {
+			total += v
+		}
+		for _, k := range arityTypes {
+			printf("%s%-4s %10d (%.2f %%)\n", indent, k, arity[k], percent(arity[k], total))
+		}
+	}
+	printf("Items: %d\n", st.NumItems)
+	if st.ErrorRows > 0 {
+		printf("Error rows: %d\n", st.ErrorRows)
+	}
+	printf("\nArity:\n")
+	printArity(st.Arity, "  ")
+	printf("\nCoverage:\n")
+	printf("  %s: %d of %d structures aligned (%.2f %%)\n", st.Corpus1, st.Aligned1, st.Size1, st.Coverage1)
+	printf("  %s: %d of %d structures aligned (%.2f %%)\n", st.Corpus2, st.Aligned2, st.Size2, st.Coverage2)
+	printf("\nGaps: %d rows, %d structures\n", st.GapRows, st.GapSize)
+	printf("\nLongest ranges:\n")
+	for _, lr := range st.Longest {
+		printf("  line %d: %s -> %s (%d -> %d)\n", lr.Line, lr.From, lr.To, lr.Size1, lr.Size2)
+	}
+	if len(st.Documents) > 0 {
+		printf("\nDocuments:\n")
+		for _, doc := range st.Documents {
+			printf("  %s: coverage %.2f %% -> %.2f %%\n", doc.ID, doc.Coverage1, doc.Coverage2)
+			printArity(doc.Arity, "    ")
+		}
+	}
+	return err
+}
+
+// ------------------------------------------------------------
+
+// Collector calculates statistics of alignment items
+type Collector struct {
+	attr1  attrib.PosAttr
+	attr2  attrib.PosAttr
+	filter export.GroupFilter
+	stats  *Stats
+	docs   map[string]*DocStats
+}
+
+func (c *Collector) doc(ident string) *DocStats {
+	ans, ok := c.docs[ident]
+	if !ok {
+		ans = &DocStats{ID: ident, Arity: newArity()}
+		c.docs[ident] = ans
+		c.stats.Documents = append(c.stats.Documents, ans)
+	}
+	return ans
+}
+
+func (c *Collector) addLongRange(line int, item mapping.Mapping, size1, size2 int) {
+	longest := c.stats.Longest
+	if len(longest) == NumLongestRanges {
+		last := longest[len(longest)-1]
+		if maxInt(size1, size2) <= maxInt(last.Size1, last.Size2) {
+			return
+		}
+		longest = longest[:len(longest)-1]
+	}
+	longest = append(longest, &LongRange{
+		Line:  line,
+		From:  item.From.String(),
+		To:    item.To.String(),
+		Size1: size1,
+		Size2: size2,
+	})
+	sort.SliceStable(longest, func(i, j int) bool {
+		return maxInt(longest[i].Size1, longest[i].Size2) > maxInt(longest[j].Size1, longest[j].Size2)
+	})
+	c.stats.Longest = longest
+}
+
+func maxInt(v1, v2 int) int {
+	if v1 > v2 {
+		return v1
+	}
+	return v2
+}
+
+// addUnaligned counts structures of a one-sided range (per document
+// in case a group filter is available)
+func (c *Collector) addUnaligned(rng mapping.PosRange, attr attrib.PosAttr, arity string, first bool) {
+	c.stats.Arity[arity] += rangeSize(rng)
+	if c.filter == nil {
+		return
+	}
+	for i := rng.First; i <= rng.Last; i++ {
+		doc := c.doc(c.filter.ExtractGroupID(attr.ID2Str(i)))
+		doc.Arity[arity]++
+		if first {
+			doc.Unaligned1++
+
+		} else {
+			doc.Unaligned2++
+		}
+	}
+}
+
+// Add adds an alignment item to the statistics
+func (c *Collector) Add(line int, item mapping.Mapping) {
+	c.stats.NumItems++
+	if item.IsError() {
+		c.stats.ErrorRows++
+		return
+	}
+	size1 := rangeSize(item.From)
+	size2 := rangeSize(item.To)
+	if item.IsGap {
+		c.stats.GapRows++
+		c.stats.GapSize += size1 + size2
+	}
+	if size1 == 0 {
+		c.addUnaligned(item.To, c.attr2, "0-1", false)
+
+	} else if size2 == 0 {
+		c.addUnaligned(item.From, c.attr1, "1-0", true)
+
+	} else {
+		arity := arityType(size1, size2)
+		c.stats.Arity[arity]++
+		c.stats.Aligned1 += size1
+		c.stats.Aligned2 += size2
+		c.addLongRange(line, item, size1, size2)
+		if c.filter != nil {
+			doc := c.doc(c.filter.ExtractGroupID(c.attr1.ID2Str(item.From.First)))
+			doc.Arity[arity]++
+			doc.Aligned1 += size1
+			doc.Aligned2 += size2
+		}
+	}
+}
+
+// Result returns calculated statistics
+func (c *Collector) Result() *Stats {
+	c.stats.Coverage1 = percent(c.stats.Aligned1, c.stats.Size1)
+	c.stats.Coverage2 = percent(c.stats.Aligned2, c.stats.Size2)
+	for _, doc := range c.stats.Documents {
+		doc.Coverage1 = percent(doc.Aligned1, doc.Aligned1+doc.Unaligned1)
+		doc.Coverage2 = percent(doc.Aligned2, doc.Aligned2+doc.Unaligned2)
+	}
+	return c.stats
+}
+
+// Run reads a numeric alignment (text or binary) from src and adds
+// all its items to the statistics. In case the data contain a header,
+// it is compared with the expected one. Invalid items are logged
+// and skipped.
+func (c *Collector) Run(src io.Reader, expected mapping.Header) error {
+	reader, err := mapping.NewReader(src)
+	if err != nil {
+		return err
+	}
+	if err := reader.Header().Verify(expected); err != nil {
+		return err
+	}
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			return nil
+
+		} else if _, ok := err.(*mapping.ParseError); ok {
+			log.Print("WARNING: ", err)
+			continue
+
+		} else if err != nil {
+			return err
+		}
+		c.Add(reader.Line(), item)
+	}
+}
+
+// NewCollector creates a new Collector instance for an alignment
+// of corpora corpus1 and corpus2 with respective structure sizes
+// and string IDs (attr1, attr2). In case a group filter is provided,
+// statistics of individual documents are calculated too.
+func NewCollector(
+	corpus1 string,
+	corpus2 string,
+	attr1 attrib.PosAttr,
+	attr2 attrib.PosAttr,
+	size1 int,
+	size2 int,
+	filter export.GroupFilter,
+) *Collector {
+	return &Collector{
+		attr1:  attr1,
+		attr2:  attr2,
+		filter: filter,
+		docs:   make(map[string]*DocStats),
+		stats: &Stats{
+			Corpus1: corpus1,
+			Corpus2: corpus2,
+			Size1:   size1,
+			Size2:   size2,
+			Arity:   newArity(),
+			Longest: make([]*LongRange, 0, NumLongestRanges),
+		},
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package stats
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/czcorpus/ictools/export"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func collect(data string, filter export.GroupFilter) *Stats {
+	attr1 := &attribtest.ListAttr{IDs: []string{"cs:doc1:0:1:1", "cs:doc1:0:1:2", "cs:doc1:0:1:3", "cs:doc2:0:1:1", "cs:doc2:0:1:2", "cs:doc2:0:1:3"}}
+	attr2 := &attribtest.ListAttr{IDs: []string{"en:doc1:0:1:1", "en:doc1:0:1:2", "en:doc2:0:1:1", "en:doc2:0:1:2", "en:doc2:0:1:3"}}
+	c := NewCollector("cs", "en", attr1, attr2, 6, 5, filter)
+	c.Run(strings.NewReader(data), mapping.Header{})
+	return c.Result()
+}
+
+const testData = "0,1\t0\n2\t-1\n3\t1,3\n-1\t4\tg\n4,5\t-1\tg\n"
+
+func TestArity(t *testing.T) {
+	st := collect(testData, nil)
+	assert.Equal(t, 5, st.NumItems)
+	assert.Equal(t, 0, st.Arity["1-1"])
+	assert.Equal(t, 1, st.Arity["2-1"])
+	assert.Equal(t, 1, st.Arity["n-m"])
+	assert.Equal(t, 1, st.Arity["0-1"])
+	assert.Equal(t, 3, st.Arity["1-0"])
+	assert.Nil(t, st.Documents)
+}
+
+func TestCoverageAndGaps(t *testing.T) {
+	st := collect(testData, nil)
+	assert.Equal(t, 3, st.Aligned1)
+	assert.Equal(t, 4, st.Aligned2)
+	assert.InDelta(t, 50.0, st.Coverage1, 0.001)
+	assert.InDelta(t, 80.0, st.Coverage2, 0.001)
+	assert.Equal(t, 2, st.GapRows)
+	assert.Equal(t, 3, st.GapSize)
+}
+
+func TestLongestRanges(t *testing.T) {
+	st := collect(testData, nil)
+	assert.Equal(t, 2, len(st.Longest))
+	assert.Equal(t, 3, st.Longest[0].Line)
+	assert.Equal(t, "1,3", st.Longest[0].To)
+	assert.Equal(t, 1, st.Longest[1].Line)
+}
+
+func TestLongestRangesLimit(t *testing.T) {
+	var buff strings.Builder
+	for i := 0; i < 2*NumLongestRanges; i++ {
+		buff.WriteString("0\t0\n")
+	}
+	buff.WriteString("1,2\t1\n")
+	st := collect(buff.String(), nil)
+	assert.Equal(t, NumLongestRanges, len(st.Longest))
+	assert.Equal(t, 2*NumLongestRanges+1, st.Longest[0].Line)
+	assert.Equal(t, 1, st.Longest[1].Line)
+}
+
+func TestDocuments(t *testing.T) {
+	st := collect(testData, export.NewGroupFilter(export.ExportTypeIntercorp))
+	assert.Equal(t, 2, len(st.Documents))
+	assert.Equal(t, "doc1", st.Documents[0].ID)
+	assert.Equal(t, 2, st.Documents[0].Aligned1)
+	assert.Equal(t, 1, st.Documents[0].Unaligned1)
+	assert.Equal(t, 1, st.Documents[0].Arity["1-0"])
+	assert.Equal(t, "doc2", st.Documents[1].ID)
+	assert.Equal(t, 1, st.Documents[1].Arity["0-1"])
+	assert.Equal(t, 2, st.Documents[1].Arity["1-0"])
+	assert.InDelta(t, 75.0, st.Documents[1].Coverage2, 0.001)
+}
+
+func TestWriteJSON(t *testing.T) {
+	st := collect(testData, nil)
+	var buff bytes.Buffer
+	assert.Nil(t, st.WriteJSON(&buff))
+	var decoded Stats
+	assert.Nil(t, json.Unmarshal(buff.Bytes(), &decoded))
+	assert.Equal(t, st.Arity, decoded.Arity)
+	assert.Equal(t, st.GapSize, decoded.GapSize)
+}
+
+func TestWriteText(t *testing.T) {
+	st := collect(testData, nil)
+	var buff bytes.Buffer
+	assert.Nil(t, st.WriteText(&buff))
+	assert.Contains(t, buff.String(), "cs: 3 of 6 structures aligned (50.00 %)")
+	assert.Contains(t, buff.String(), "Gaps: 2 rows, 3 structures")
+}
+
+func TestHeaderMismatch(t *testing.T) {
+	c := NewCollector("cs", "en", &attribtest.ListAttr{}, &attribtest.ListAttr{}, 1, 1, nil)
+	err := c.Run(strings.NewReader("#@ corpus1=ic_pl\n0\t0\n#@ checksum=crc32:671bcf4d\n"), mapping.Header{Corpus1: "ic_cs"})
+	assert.Error(t, err)
+}
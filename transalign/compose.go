@@ -0,0 +1,109 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transalign
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// DetectChainInversions determines for each mapping of a chain
+// LANG1 -> LANG2, LANG2 -> LANG3, ..., LANGn-1 -> LANGn whether
+// it is inverted (i.e. whether it is a LANGi+1 -> LANGi mapping).
+// The languages are matched using corpora of subsequent mapping headers.
+// In case some of the headers does not contain corpora names, all
+// the mappings are expected to be non-inverted.
+func DetectChainInversions(headers []mapping.Header) ([]bool, error) {
+	if len(headers) < 2 {
+		return nil, fmt.Errorf("At least two mappings are required")
+	}
+	ans := make([]bool, len(headers))
+	for _, h := range headers {
+		if h.Corpus1 == "" || h.Corpus2 == "" {
+			return ans, nil
+		}
+	}
+	for i := 1; i < len(headers); i++ {
+		// mapping i-1 must have the shared language on its "right" side,
+		// mapping i on its "left" side
+		left0, left, err := DetectPivotSides(headers[i-1], headers[i])
+		if err != nil {
+			return nil, err
+		}
+		if i > 1 && left0 != ans[i-1] {
+			return nil, fmt.Errorf("Cannot determine direction of mapping %s -> %s within the chain",
+				headers[i-1].Corpus1, headers[i-1].Corpus2)
+		}
+		ans[i-1] = left0
+		ans[i] = !left
+	}
+	return ans, nil
+}
+
+// ComposeHeader derives a header of LANG1 -> LANGn mapping from
+// headers of a chain of mappings (see DetectChainInversions for
+// the meaning of the inverted argument). Subsequent mappings are
+// verified to share their intermediate corpus (see VerifyHeaders);
+// the header is returned even if the verification fails.
+func ComposeHeader(headers []mapping.Header, inverted []bool) (mapping.Header, error) {
+	oriented := func(i int) mapping.Header {
+		if inverted[i] {
+			return headers[i].Inverted()
+		}
+		return headers[i]
+	}
+	var err error
+	ans := oriented(0)
+	for i := 1; i < len(headers); i++ {
+		next := oriented(i).Inverted() // LANGi+1 -> LANGi (i.e. LANG -> PIVOT)
+		if err == nil {
+			err = VerifyHeaders(ans, next)
+		}
+		ans = CreateHeader(ans, next)
+	}
+	return ans, err
+}
+
+// Compose generates LANG1 -> LANGn mapping from a chain of mappings
+// through intermediate languages LANG2, ..., LANGn-1. The first mapping
+// must be loaded as LANG1 -> LANG2 (i.e. with LANG2 as the pivot) and
+// each following one as LANGi+1 -> LANGi (i.e. with the previous language
+// as the pivot; see PivotMapping.SetPivotLeft). The chain is processed
+// by repeated Run calls where each intermediate result serves as the first
+// pivot mapping of the next step. As gaps are preserved in the intermediate
+// results, alignments are never extended across them in any of the steps.
+func Compose(mappings []*PivotMapping, onItem func(mapping.Mapping)) error {
+	if len(mappings) < 2 {
+		return fmt.Errorf("At least two mappings are required")
+	}
+	curr := mappings[0]
+	for i := 1; i < len(mappings)-1; i++ {
+		log.Printf("INFO: Composing mapping %d of %d...", i+1, len(mappings))
+		items := make([]mapping.Mapping, 0, curr.Size())
+		Run(curr, mappings[i], func(item mapping.Mapping) {
+			if !item.IsEmpty() {
+				items = append(items, item)
+			}
+		})
+		curr = NewPivotMappingFromItems(CreateHeader(curr.Header(), mappings[i].Header()), items)
+	}
+	log.Printf("INFO: Composing mapping %d of %d...", len(mappings), len(mappings))
+	Run(curr, mappings[len(mappings)-1], onItem)
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transalign
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/calign"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+// identityData creates a numeric mapping aligning each
+// of all the LANG structures of data with itself
+func identityData(data string) string {
+	size := 0
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		item, err := mapping.NewMappingFromString(line)
+		if err != nil {
+			panic(err)
+		}
+		if item.From.Last+1 > size {
+			size = item.From.Last + 1
+		}
+	}
+	var ans strings.Builder
+	for i := 0; i < size; i++ {
+		ans.WriteString(fmt.Sprintf("%d\t%d\n", i, i))
+	}
+	return ans.String()
+}
+
+// compressGaps merges subsequent one-sided gap rows of a numeric mapping
+// (composition through a 1-1 mapping splits them into individual rows)
+func compressGaps(data string) string {
+	items := make([]mapping.Mapping, 0, 100)
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		item, err := mapping.NewMappingFromString(line)
+		if err != nil {
+			panic(err)
+		}
+		items = append(items, item)
+	}
+	ch := make(chan []mapping.Mapping, 1)
+	ch <- items
+	close(ch)
+	var ans strings.Builder
+	calign.CompressFromChan(ch, true, func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	return ans.String()
+}
+
+func runCompose(pms ...*PivotMapping) string {
+	var ans strings.Builder
+	err := Compose(pms, func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	if err != nil {
+		panic(err)
+	}
+	return ans.String()
+}
+
+func TestComposeTwoSameAsRun(t *testing.T) {
+	rnd := rand.New(rand.NewSource(45))
+	for i := 0; i < 50; i++ {
+		pivotSize := 10 + rnd.Intn(300)
+		data1 := generateImportData(rnd, pivotSize)
+		data2 := generateImportData(rnd, pivotSize)
+		assert.Equal(
+			t,
+			runInMemory(data1, data2),
+			runCompose(loadPivotMapping(data1, false), loadPivotMapping(data2, false)),
+		)
+	}
+}
+
+func TestComposeThroughIdentity(t *testing.T) {
+	rnd := rand.New(rand.NewSource(46))
+	for i := 0; i < 50; i++ {
+		pivotSize := 10 + rnd.Intn(300)
+		data1 := generateInvertibleData(rnd, pivotSize)
+		data2 := generateInvertibleData(rnd, pivotSize)
+		expected := compressGaps(runInMemory(data1, data2))
+		ident := identityData(data2)
+		// L1 -> P, P -> L2 (inverted), L2 -> L2
+		ans := runCompose(
+			loadPivotMapping(data1, false),
+			loadPivotMapping(invertData(data2), true),
+			loadPivotMapping(ident, false),
+		)
+		if !assert.Equal(t, expected, compressGaps(ans)) {
+			t.Logf("L1 -> P:\n%s\nL2 -> P:\n%s", data1, data2)
+			return
+		}
+		// P -> L1 (inverted), L2 -> P, L2 -> L2
+		ans = runCompose(
+			loadPivotMapping(invertData(data1), true),
+			loadPivotMapping(data2, false),
+			loadPivotMapping(invertData(ident), true),
+		)
+		assert.Equal(t, expected, compressGaps(ans))
+	}
+}
+
+func TestComposeKeepsGaps(t *testing.T) {
+	pm1 := loadPivotMapping("0\t0\n-1\t1,2\tg\n1\t3\n", false)
+	ident := loadPivotMapping("0\t0\n1\t1\n2\t2\n3\t3\n", false)
+	assert.Equal(
+		t,
+		"0\t0\n-1\t1\tg\n-1\t2\tg\n1\t3\n",
+		runCompose(pm1, ident, ident),
+	)
+
+	// without the gap flag, the pivot range is not split
+	pm1 = loadPivotMapping("0\t0\n-1\t1,2\n1\t3\n", false)
+	assert.Equal(
+		t,
+		"0\t0\n-1\t1,2\n1\t3\n",
+		runCompose(pm1, ident, ident),
+	)
+}
+
+func TestComposeRequiresTwoMappings(t *testing.T) {
+	pm := loadPivotMapping("0\t0\n", false)
+	assert.Error(t, Compose([]*PivotMapping{pm}, func(item mapping.Mapping) {}))
+}
+
+func TestDetectChainInversions(t *testing.T) {
+	sk2cs := mapping.Header{Corpus1: "ic_sk", Corpus2: "ic_cs"}
+	cs2en := mapping.Header{Corpus1: "ic_cs", Corpus2: "ic_en"}
+	en2cs := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_cs"}
+	fr2en := mapping.Header{Corpus1: "ic_fr", Corpus2: "ic_en"}
+	en2fr := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_fr"}
+
+	ans, err := DetectChainInversions([]mapping.Header{sk2cs, cs2en, en2fr})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, false, false}, ans)
+
+	ans, err = DetectChainInversions([]mapping.Header{sk2cs, en2cs, fr2en})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, true, true}, ans)
+
+	ans, err = DetectChainInversions([]mapping.Header{sk2cs.Inverted(), cs2en.Inverted()})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{true, true}, ans)
+
+	ans, err = DetectChainInversions([]mapping.Header{sk2cs, {}, en2fr})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, false, false}, ans)
+
+	_, err = DetectChainInversions([]mapping.Header{sk2cs, en2fr})
+	assert.Error(t, err)
+
+	_, err = DetectChainInversions([]mapping.Header{sk2cs, cs2en, sk2cs.Inverted()})
+	assert.Error(t, err)
+
+	_, err = DetectChainInversions([]mapping.Header{sk2cs})
+	assert.Error(t, err)
+}
+
+func TestComposeHeader(t *testing.T) {
+	sk2cs := mapping.Header{Corpus1: "ic_sk", Corpus2: "ic_cs", Attr: "s.id", Size1: 10, Size2: 20}
+	en2cs := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_cs", Attr: "s.id", Size1: 30, Size2: 20}
+	en2fr := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_fr", Attr: "s.id", Size1: 30, Size2: 40}
+
+	ans, err := ComposeHeader([]mapping.Header{sk2cs, en2cs, en2fr}, []bool{false, true, false})
+	assert.Nil(t, err)
+	assert.Equal(t, mapping.Header{Corpus1: "ic_sk", Corpus2: "ic_fr", Attr: "s.id", Size1: 10, Size2: 40}, ans)
+
+	en2fr.Size1 = 31
+	ans, err = ComposeHeader([]mapping.Header{sk2cs, en2cs, en2fr}, []bool{false, true, false})
+	assert.Error(t, err)
+	assert.Equal(t, "ic_fr", ans.Corpus2)
+}
@@ -20,12 +20,12 @@
 package transalign
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
-	"strings"
+	"sort"
 
 	"github.com/czcorpus/ictools/common"
 	"github.com/czcorpus/ictools/mapping"
@@ -35,6 +35,10 @@ const (
 	// A magical constant to estimate number of lines
 	// of a PivotMapping based on source file size.
 	fileToCapacityRatio = 14
+
+	// initial capacity of a PivotMapping in case
+	// the source size is not known
+	defaultItemsEstim = 100000
 )
 
 // PosRangeMap maps data rows to PosRange values
@@ -48,11 +52,14 @@ type PosRangeMap map[int]mapping.PosRange
 // is stored as r1, r1+1, ..., r2-1, r2 (but each
 // line still knows the original range it belongs to)
 type PivotMapping struct {
-	// source file
-	file *os.File
+	// source data
+	src io.Reader
+
+	// name of the source used for logging
+	sourceName string
 
-	// source file reader
-	reader *bufio.Scanner
+	// information about the data (available after Load())
+	header mapping.Header
 
 	// list of lines mapping language ranges to pivot ranges (pivot language).
 	ranges []*mapping.PosRange
@@ -64,8 +71,16 @@ type PivotMapping struct {
 	// These rows cannot be used to expand translation range.
 	gaps map[int]bool
 
+	// meta contains optional link metadata (only rows with
+	// some metadata are stored here)
+	meta map[int]mapping.LinkMeta
+
 	// estimation of items number for efficient memory pre-allocation
 	itemsEstim int
+
+	// pivotLeft specifies that the source is a PIVOT -> LANG mapping
+	// (i.e. ranges and pivots are read from swapped columns)
+	pivotLeft bool
 }
 
 // NewPivotMapping creates a new instance of PivotMapping
@@ -79,14 +94,34 @@ func NewPivotMapping(file *os.File) (*PivotMapping, error) {
 	initialCap := fSize / fileToCapacityRatio
 	log.Printf("INFO: pivot mapping size estimation for %s: %d",
 		filepath.Base(file.Name()), initialCap)
+	return newPivotMapping(file, file.Name(), initialCap), nil
+}
+
+// NewPivotMappingFromReader creates a new instance of PivotMapping
+// reading its data (text or binary) from src. The sourceName argument
+// is used just for logging. No data is loaded in this function
+// (see PivotRange.Load()).
+func NewPivotMappingFromReader(src io.Reader, sourceName string) *PivotMapping {
+	return newPivotMapping(src, sourceName, defaultItemsEstim)
+}
+
+func newPivotMapping(src io.Reader, sourceName string, initialCap int) *PivotMapping {
 	return &PivotMapping{
-		file:       file,
-		reader:     bufio.NewScanner(file),
+		src:        src,
+		sourceName: sourceName,
 		ranges:     make([]*mapping.PosRange, 0, initialCap),
 		pivots:     make([]*mapping.PosRange, 0, initialCap),
 		itemsEstim: initialCap,
 		gaps:       make(map[int]bool),
-	}, nil
+		meta:       make(map[int]mapping.LinkMeta),
+	}
+}
+
+// SetPivotLeft specifies whether the pivot language is in the left
+// column of the source (PIVOT -> LANG mapping) instead of the right one.
+// The method must be called before Load().
+func (hm *PivotMapping) SetPivotLeft(pivotLeft bool) {
+	hm.pivotLeft = pivotLeft
 }
 
 // Size returns number of mapping definitions
@@ -109,33 +144,232 @@ func (hm *PivotMapping) HasGapAtRow(idx int) bool {
 	return hm.gaps[idx]
 }
 
-// Load loads the respective data from a predefined file.
-func (hm *PivotMapping) Load() error {
+// Row returns language range, pivot range and link metadata
+// of a specified row. The last value is false if there is no such row.
+func (hm *PivotMapping) Row(idx int) (mapping.PosRange, mapping.PosRange, mapping.LinkMeta, bool) {
+	if idx >= len(hm.ranges) {
+		return mapping.PosRange{}, mapping.PosRange{}, mapping.LinkMeta{}, false
+	}
+	return *hm.ranges[idx], *hm.pivots[idx], hm.meta[idx], true
+}
+
+// MetaAtRow returns link metadata of a specified row
+// (an empty value is returned if there are no metadata).
+func (hm *PivotMapping) MetaAtRow(idx int) mapping.LinkMeta {
+	return hm.meta[idx]
+}
 
-	log.Printf("INFO: Loading %s ...", hm.file.Name())
-	var i int
-	for hm.reader.Scan() {
-		elms := strings.Split(hm.reader.Text(), "\t")
-		if elms[0] == mapping.ErrorMark {
-			return fmt.Errorf("Refusing to continue due to the 'ERROR' mark in the source file")
+// pivotRow is a row of a LANG -> PIVOT numeric mapping
+type pivotRow struct {
+	lang  mapping.PosRange
+	pivot mapping.PosRange
+	isGap bool
+	meta  mapping.LinkMeta
+}
+
+// readPivotRow reads next row of a LANG -> PIVOT numeric mapping
+// (or a PIVOT -> LANG one in case pivotLeft is true).
+// At the end of data, io.EOF is returned.
+func readPivotRow(reader mapping.Reader, pivotLeft bool) (pivotRow, error) {
+	item, err := reader.Read()
+	if err == io.EOF {
+		return pivotRow{}, err
+
+	} else if err != nil {
+		return pivotRow{}, fmt.Errorf("ERROR: Failed to read pivot mapping: %s", err)
+
+	} else if item.IsError() {
+		return pivotRow{}, fmt.Errorf("Refusing to continue due to the 'ERROR' mark in the source file")
+	}
+	if pivotLeft {
+		return pivotRow{
+			lang:  item.To,
+			pivot: item.From,
+			isGap: item.IsGap,
+			meta:  item.Meta,
+		}, nil
+	}
+	// the mapping in the file is (SOME_LANG -> PIVOT_LANG)
+	return pivotRow{
+		lang:  item.From,
+		pivot: item.To,
+		isGap: item.IsGap,
+		meta:  item.Meta,
+	}, nil
+}
+
+// langOrderer reorders rows of a PIVOT -> LANG mapping (i.e. rows ordered
+// by the pivot positions) to the order of a respective LANG -> PIVOT mapping
+// as produced by 'import'. Non-gap rows keep their order while each gap row
+// is moved right before the next non-gap row with the respective language
+// (LANG gaps go first, then the PIVOT ones).
+type langOrderer struct {
+	langGaps  []pivotRow
+	pivotGaps []pivotRow
+}
+
+// add processes next row of a PIVOT -> LANG mapping. The rows which
+// are ready to be used are passed to the emit function.
+func (lo *langOrderer) add(row pivotRow, emit func(pivotRow)) {
+	if row.isGap && row.pivot.First == -1 {
+		lo.langGaps = append(lo.langGaps, row)
+		return
+
+	} else if row.isGap && row.lang.First == -1 {
+		lo.pivotGaps = append(lo.pivotGaps, row)
+		return
+	}
+	if row.lang.First != -1 {
+		for len(lo.langGaps) > 0 && lo.langGaps[0].lang.First < row.lang.First {
+			emit(lo.langGaps[0])
+			lo.langGaps = lo.langGaps[1:]
 		}
-		// the mapping in the file is (SOME_LANG -> PIVOT_LANG)
-		pivot := strings.Split(elms[1], ",")
-		l2 := strings.Split(elms[0], ",")
-		pivotPair, err1 := mapping.NewPosRange(pivot)
-		if err1 != nil {
-			return fmt.Errorf("ERROR: Failed to parse pivot on line %d: %s", i, err1)
+	}
+	if row.pivot.First != -1 {
+		for len(lo.pivotGaps) > 0 && lo.pivotGaps[0].pivot.First < row.pivot.First {
+			emit(lo.pivotGaps[0])
+			lo.pivotGaps = lo.pivotGaps[1:]
 		}
-		l2Pair, err2 := mapping.NewPosRange(l2)
-		if err2 != nil {
-			return fmt.Errorf("ERROR: Failed to parse other lang on line %d: %s", i, err2)
+	}
+	emit(row)
+}
+
+// flush emits all the remaining gap rows
+func (lo *langOrderer) flush(emit func(pivotRow)) {
+	for _, row := range lo.langGaps {
+		emit(row)
+	}
+	for _, row := range lo.pivotGaps {
+		emit(row)
+	}
+	lo.langGaps = lo.langGaps[:0]
+	lo.pivotGaps = lo.pivotGaps[:0]
+}
+
+// size returns number of rows waiting for a next non-gap row
+func (lo *langOrderer) size() int {
+	return len(lo.langGaps) + len(lo.pivotGaps)
+}
+
+// orderByLang reorders rows of a PIVOT -> LANG mapping to the order
+// of a respective LANG -> PIVOT mapping (see langOrderer). LANG-only
+// rows behind a row with a higher language position are moved back
+// first (in front of the next row with a higher language position).
+// Please note that the original order of a non-gap LANG-only row and
+// an adjacent non-gap PIVOT-only one cannot be restored.
+func orderByLang(rows []pivotRow) []pivotRow {
+	ordered := make([]pivotRow, 0, len(rows))
+	late := make([]pivotRow, 0, 100)
+	lastLang := -1
+	for _, row := range rows {
+		if row.pivot.First == -1 && row.lang.First != -1 && row.lang.First <= lastLang {
+			late = append(late, row)
+			continue
+		}
+		if row.lang.First != -1 {
+			lastLang = row.lang.Last
+		}
+		ordered = append(ordered, row)
+	}
+	sort.SliceStable(late, func(i, j int) bool {
+		return late[i].lang.First < late[j].lang.First
+	})
+	ans := make([]pivotRow, 0, len(rows))
+	emit := func(row pivotRow) {
+		ans = append(ans, row)
+	}
+	var orderer langOrderer
+	for _, row := range ordered {
+		if row.lang.First != -1 {
+			for len(late) > 0 && late[0].lang.First < row.lang.First {
+				orderer.add(late[0], emit)
+				late = late[1:]
+			}
+		}
+		orderer.add(row, emit)
+	}
+	for _, row := range late {
+		orderer.add(row, emit)
+	}
+	orderer.flush(emit)
+	return ans
+}
+
+// Header returns information about the loaded data
+// (available after Load() is called). The header always
+// describes a LANG -> PIVOT mapping (i.e. it is inverted
+// in case the pivot is in the left column).
+func (hm *PivotMapping) Header() mapping.Header {
+	if hm.pivotLeft {
+		return hm.header.Inverted()
+	}
+	return hm.header
+}
+
+// Load loads the respective data from a predefined file.
+// Rows of a PIVOT -> LANG mapping are reordered by the language
+// positions first (see orderByLang) as the source is ordered by
+// the pivot.
+func (hm *PivotMapping) Load() error {
+
+	log.Printf("INFO: Loading %s ...", hm.sourceName)
+	reader, err := mapping.NewReader(hm.src)
+	if err != nil {
+		return err
+	}
+	hm.header = reader.Header()
+	var pivotLeftRows []pivotRow
+	for {
+		row, err := readPivotRow(reader, hm.pivotLeft)
+		if err == io.EOF {
+			break
+
+		} else if err != nil {
+			return err
 		}
+		if hm.pivotLeft {
+			pivotLeftRows = append(pivotLeftRows, row)
 
-		hm.ranges = append(hm.ranges, &l2Pair)
-		hm.pivots = append(hm.pivots, &pivotPair)
-		i = len(hm.ranges) - 1
-		hm.gaps[i] = len(elms) == 3
+		} else {
+			hm.addRow(row)
+		}
+	}
+	for _, row := range orderByLang(pivotLeftRows) {
+		hm.addRow(row)
 	}
 	log.Printf("INFO: ...Done (%d items).", len(hm.ranges))
 	return nil
 }
+
+func (hm *PivotMapping) addRow(row pivotRow) {
+	hm.ranges = append(hm.ranges, &row.lang)
+	hm.pivots = append(hm.pivots, &row.pivot)
+	i := len(hm.ranges) - 1
+	hm.gaps[i] = row.isGap
+	if !row.meta.IsEmpty() {
+		hm.meta[i] = row.meta
+	}
+}
+
+// NewPivotMappingFromItems creates a loaded PivotMapping from
+// LANG -> PIVOT mapping items already available in memory (e.g. from
+// a result of Run). Gap flags and link metadata of the items are kept.
+func NewPivotMappingFromItems(header mapping.Header, items []mapping.Mapping) *PivotMapping {
+	ans := &PivotMapping{
+		header:     header,
+		ranges:     make([]*mapping.PosRange, 0, len(items)),
+		pivots:     make([]*mapping.PosRange, 0, len(items)),
+		itemsEstim: len(items),
+		gaps:       make(map[int]bool),
+		meta:       make(map[int]mapping.LinkMeta),
+	}
+	for _, item := range items {
+		ans.addRow(pivotRow{
+			lang:  item.From,
+			pivot: item.To,
+			isGap: item.IsGap,
+			meta:  item.Meta,
+		})
+	}
+	return ans
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transalign
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// PivotStream is a sequential alternative to PivotMapping. It keeps
+// just the current row in memory. Rows can be accessed only in
+// the order they are stored in the source.
+//
+// The source must be ordered by both the language and the pivot
+// positions (which is always true for data produced by 'import').
+// In case the language positions are not increasing, an error
+// is reported (see Err()). Rows of a PIVOT -> LANG source are
+// reordered the same way as in PivotMapping.Load() but LANG-only
+// rows cannot be moved back behind rows with a higher language
+// position (which is not needed for data produced by 'invert').
+type PivotStream struct {
+	reader   mapping.Reader
+	currIdx  int
+	curr     pivotRow
+	finished bool
+	err      error
+
+	// lastLang is the highest language position read so far
+	lastLang int
+
+	// pivotLeft specifies that the source is a PIVOT -> LANG mapping
+	pivotLeft bool
+
+	// orderer reorders rows of a PIVOT -> LANG mapping
+	orderer langOrderer
+
+	// pending contains already reordered rows of a PIVOT -> LANG
+	// mapping waiting to be read
+	pending []pivotRow
+
+	// sourceFinished specifies that all the rows have been read
+	// from the reader (but there still may be some pending ones)
+	sourceFinished bool
+}
+
+// maxPendingRows specifies a maximum number of gap rows
+// of a PIVOT -> LANG mapping waiting to be reordered
+const maxPendingRows = 100000
+
+// NewPivotStream creates a new PivotStream reading from src
+// (the data format is detected automatically).
+func NewPivotStream(src io.Reader) (*PivotStream, error) {
+	reader, err := mapping.NewReader(src)
+	if err != nil {
+		return nil, err
+	}
+	return &PivotStream{
+		reader:   reader,
+		currIdx:  -1,
+		lastLang: -1,
+	}, nil
+}
+
+// SetPivotLeft specifies whether the pivot language is in the left
+// column of the source (PIVOT -> LANG mapping) instead of the right one.
+// The method must be called before any row is read.
+func (ps *PivotStream) SetPivotLeft(pivotLeft bool) {
+	ps.pivotLeft = pivotLeft
+}
+
+// readPivotRow reads next row either directly from the reader or (in case
+// of a PIVOT -> LANG mapping) via the orderer (see langOrderer).
+func (ps *PivotStream) readPivotRow() (pivotRow, error) {
+	if !ps.pivotLeft {
+		return readPivotRow(ps.reader, false)
+	}
+	emit := func(row pivotRow) {
+		ps.pending = append(ps.pending, row)
+	}
+	for len(ps.pending) == 0 && !ps.sourceFinished {
+		row, err := readPivotRow(ps.reader, true)
+		if err == io.EOF {
+			ps.sourceFinished = true
+			ps.orderer.flush(emit)
+			break
+
+		} else if err != nil {
+			return pivotRow{}, err
+		}
+		ps.orderer.add(row, emit)
+		if ps.orderer.size() > maxPendingRows {
+			return pivotRow{}, fmt.Errorf(
+				"ERROR: Too many gap rows to be reordered by language positions (line %d)",
+				ps.reader.Line())
+		}
+	}
+	if len(ps.pending) == 0 {
+		return pivotRow{}, io.EOF
+	}
+	row := ps.pending[0]
+	ps.pending = ps.pending[1:]
+	return row, nil
+}
+
+func (ps *PivotStream) readNext() bool {
+	if ps.finished || ps.err != nil {
+		return false
+	}
+	row, err := ps.readPivotRow()
+	if err == io.EOF {
+		ps.finished = true
+		return false
+
+	} else if err != nil {
+		ps.err = err
+		return false
+	}
+	if row.lang.First != -1 {
+		if row.lang.First <= ps.lastLang {
+			ps.err = fmt.Errorf(
+				"ERROR: Language positions not ordered on line %d (%d after %d); streaming requires data produced by 'import'",
+				ps.currIdx+1, row.lang.First, ps.lastLang)
+			return false
+		}
+		ps.lastLang = row.lang.Last
+	}
+	ps.currIdx++
+	ps.curr = row
+	return true
+}
+
+// Row returns language range, pivot range and link metadata of a specified
+// row. Only the current row or the next one can be requested.
+// The last value is false if there is no such row (or if an error occured).
+func (ps *PivotStream) Row(idx int) (mapping.PosRange, mapping.PosRange, mapping.LinkMeta, bool) {
+	if idx == ps.currIdx+1 {
+		if !ps.readNext() {
+			return mapping.PosRange{}, mapping.PosRange{}, mapping.LinkMeta{}, false
+		}
+
+	} else if idx != ps.currIdx {
+		if !ps.finished && ps.err == nil {
+			ps.err = fmt.Errorf("ERROR: Non-sequential access to row %d (current row: %d)", idx, ps.currIdx)
+		}
+		return mapping.PosRange{}, mapping.PosRange{}, mapping.LinkMeta{}, false
+	}
+	return ps.curr.lang, ps.curr.pivot, ps.curr.meta, true
+}
+
+// HasGapAtRow tests whether the current row represents
+// a gap (see PivotMapping.HasGapAtRow).
+func (ps *PivotStream) HasGapAtRow(idx int) bool {
+	return idx == ps.currIdx && ps.curr.isGap
+}
+
+// langLowerBound returns a minimum language position
+// any of the rows not read yet can start with.
+func (ps *PivotStream) langLowerBound() int {
+	return ps.lastLang + 1
+}
+
+// Header returns information about the data. The header always
+// describes a LANG -> PIVOT mapping (see PivotMapping.Header).
+func (ps *PivotStream) Header() mapping.Header {
+	if ps.pivotLeft {
+		return ps.reader.Header().Inverted()
+	}
+	return ps.reader.Header()
+}
+
+// Err returns an error encountered while reading the data
+func (ps *PivotStream) Err() error {
+	return ps.err
+}
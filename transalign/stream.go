@@ -0,0 +1,181 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transalign
+
+import (
+	"container/heap"
+	"context"
+	"log"
+
+	"github.com/czcorpus/ictools/mapping"
+)
+
+type heapItem struct {
+	item mapping.Mapping
+	key  int
+	seq  int
+}
+
+// mappingHeap is a min-heap of mappings ordered by a key
+// (either From.First or To.First); items with the same key
+// are kept in the order they were added.
+type mappingHeap []heapItem
+
+func (mh mappingHeap) Len() int {
+	return len(mh)
+}
+
+func (mh mappingHeap) Less(i, j int) bool {
+	return mh[i].key < mh[j].key || mh[i].key == mh[j].key && mh[i].seq < mh[j].seq
+}
+
+func (mh mappingHeap) Swap(i, j int) {
+	mh[i], mh[j] = mh[j], mh[i]
+}
+
+func (mh *mappingHeap) Push(x interface{}) {
+	*mh = append(*mh, x.(heapItem))
+}
+
+func (mh *mappingHeap) Pop() interface{} {
+	old := *mh
+	ans := old[len(old)-1]
+	*mh = old[:len(old)-1]
+	return ans
+}
+
+// streamMerger produces the same sequence of items as sorting
+// both the [a, b] + [a, -1] list and the [-1, b] list followed by
+// mapping.MergeMappings would do. But instead of collecting all the
+// items, it emits them as soon as it is sure no item produced later
+// can precede them. For this, watermarks (minimum positions future
+// items can start with) are used.
+type streamMerger struct {
+	l1l2     mappingHeap
+	noneL2   mappingHeap
+	w1       int
+	w2       int
+	seq      int
+	finished bool
+	onItem   func(mapping.Mapping)
+}
+
+func (sm *streamMerger) addL1L2(item mapping.Mapping) {
+	if item.From.First != -1 || item.To.First != -1 {
+		heap.Push(&sm.l1l2, heapItem{item: item, key: item.From.First, seq: sm.seq})
+		sm.seq++
+	}
+}
+
+func (sm *streamMerger) addNoneL2(item mapping.Mapping) {
+	if item.From.First != -1 || item.To.First != -1 {
+		heap.Push(&sm.noneL2, heapItem{item: item, key: item.To.First, seq: sm.seq})
+		sm.seq++
+	}
+}
+
+// setWatermarks sets minimum From.First (w1) of future [a, b] + [a, -1]
+// items and minimum To.First (w2) of future [-1, b] items and emits
+// all the items which can be emitted.
+func (sm *streamMerger) setWatermarks(w1, w2 int) {
+	sm.w1 = w1
+	sm.w2 = w2
+	sm.flush()
+}
+
+// finish tells the merger there will be no more items
+// and emits all the remaining ones.
+func (sm *streamMerger) finish() {
+	sm.finished = true
+	sm.flush()
+}
+
+func (sm *streamMerger) emit(h *mappingHeap) {
+	sm.onItem(heap.Pop(h).(heapItem).item)
+}
+
+func (sm *streamMerger) flush() {
+	for {
+		exhausted1 := sm.finished && sm.l1l2.Len() == 0
+		exhausted2 := sm.finished && sm.noneL2.Len() == 0
+		known1 := sm.l1l2.Len() > 0 && (sm.finished || sm.l1l2[0].key < sm.w1)
+		known2 := sm.noneL2.Len() > 0 && (sm.finished || sm.noneL2[0].key < sm.w2)
+
+		if exhausted1 && exhausted2 {
+			return
+
+		} else if exhausted1 && known2 {
+			sm.emit(&sm.noneL2)
+
+		} else if exhausted2 && known1 {
+			sm.emit(&sm.l1l2)
+
+		} else if known1 && known2 {
+			if sm.noneL2[0].item.To.LessThan(sm.l1l2[0].item.To) {
+				sm.emit(&sm.noneL2)
+
+			} else {
+				sm.emit(&sm.l1l2)
+			}
+
+		} else if known1 && sm.l1l2[0].item.To.First < sm.w2 {
+			// any future [-1, b] item has b > To.First of the current one
+			sm.emit(&sm.l1l2)
+
+		} else {
+			return
+		}
+	}
+}
+
+// RunStreaming produces the same output as Run but instead of loading
+// both pivot mappings into memory and sorting the whole result, it
+// reads the sources sequentially and emits items as soon as possible.
+// This requires the sources to be ordered by the language positions
+// (which is always true for data produced by 'import'); otherwise an
+// error is returned (please note that some items may have been already
+// emitted in such case).
+func RunStreaming(ps1 *PivotStream, ps2 *PivotStream, onItem func(mapping.Mapping)) error {
+	log.Print("INFO: Computing new alignment (streaming)...")
+	merger := &streamMerger{onItem: onItem}
+	align(
+		context.Background(),
+		ps1,
+		ps2,
+		merger.addL1L2,
+		merger.addNoneL2,
+		func(l1Pos, l2Pos mapping.PosRange) {
+			w1 := l1Pos.First
+			if w1 == -1 {
+				w1 = ps1.langLowerBound()
+			}
+			w2 := l2Pos.First
+			if w2 == -1 {
+				w2 = ps2.langLowerBound()
+			}
+			merger.setWatermarks(w1, w2)
+		},
+	)
+	if err := ps1.Err(); err != nil {
+		return err
+	}
+	if err := ps2.Err(); err != nil {
+		return err
+	}
+	merger.finish()
+	return nil
+}
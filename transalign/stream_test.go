@@ -0,0 +1,321 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transalign
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/czcorpus/ictools/calign"
+	"github.com/czcorpus/ictools/fixgaps"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	importBeads = [][2]int{
+		{1, 1}, {1, 1}, {1, 1}, {1, 1}, {1, 1}, {1, 1},
+		{1, 2}, {2, 1}, {2, 2}, {1, 0}, {0, 1}, {0, 3}, {3, 0},
+	}
+
+	// invertibleBeads do not produce non-gap LANG-only rows (an inverted
+	// mapping does not keep their order relative to PIVOT-only rows)
+	invertibleBeads = [][2]int{
+		{1, 1}, {1, 1}, {1, 1}, {1, 1}, {1, 1}, {1, 1},
+		{1, 2}, {2, 1}, {2, 2}, {0, 1}, {0, 3},
+	}
+)
+
+// generateImportData creates a random LANG -> PIVOT numeric mapping
+// the same way 'import' does (i.e. via fixgaps and compression).
+func generateImportData(rnd *rand.Rand, pivotSize int) string {
+	return generateBeadsData(rnd, pivotSize, importBeads)
+}
+
+// generateInvertibleData creates a random LANG -> PIVOT numeric mapping
+// (see generateImportData) which can be restored from its inverted form
+func generateInvertibleData(rnd *rand.Rand, pivotSize int) string {
+	return generateBeadsData(rnd, pivotSize, invertibleBeads)
+}
+
+func generateBeadsData(rnd *rand.Rand, pivotSize int, beads [][2]int) string {
+	items := make([]mapping.Mapping, 0, pivotSize)
+	l, p := 0, 0
+	for p < pivotSize-3 {
+		switch rnd.Intn(10) {
+		case 0: // LANG structures missing in the alignment
+			l += rnd.Intn(3) + 1
+		case 1: // PIVOT structures missing in the alignment
+			p += rnd.Intn(3) + 1
+		default:
+			bead := beads[rnd.Intn(len(beads))]
+			item := mapping.Mapping{From: mapping.NewEmptyPosRange(), To: mapping.NewEmptyPosRange()}
+			if bead[0] > 0 {
+				item.From = mapping.PosRange{First: l, Last: l + bead[0] - 1}
+			}
+			if bead[1] > 0 {
+				item.To = mapping.PosRange{First: p, Last: p + bead[1] - 1}
+			}
+			if rnd.Intn(5) == 0 {
+				item.Meta = mapping.LinkMeta{Status: mapping.StatusAuto, Score: float64(rnd.Intn(10)) / 10, HasScore: true}
+			}
+			items = append(items, item)
+			l += bead[0]
+			p += bead[1]
+		}
+	}
+	ch1 := make(chan []mapping.Mapping, 1)
+	ch1 <- items
+	close(ch1)
+	ch2 := make(chan []mapping.Mapping, 1)
+	fixed := make([]mapping.Mapping, 0, len(items))
+	fixgaps.FromChan(ch1, true, l+rnd.Intn(3), pivotSize, func(item mapping.Mapping, err *fixgaps.FixGapsError) {
+		if err != nil {
+			panic(err)
+		}
+		fixed = append(fixed, item)
+	})
+	ch2 <- fixed
+	close(ch2)
+	var ans strings.Builder
+	calign.CompressFromChan(ch2, true, func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	return ans.String()
+}
+
+func runInMemory(data1, data2 string) string {
+	return runInMemoryPivots(data1, data2, false, false)
+}
+
+// loadPivotMapping loads a PivotMapping from data (using a temporary file)
+func loadPivotMapping(data string, pivotLeft bool) *PivotMapping {
+	f, err := ioutil.TempFile("", "ictools-transalign")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	f.WriteString(data)
+	f.Seek(0, 0)
+	pm, err := NewPivotMapping(f)
+	if err != nil {
+		panic(err)
+	}
+	pm.SetPivotLeft(pivotLeft)
+	if err := pm.Load(); err != nil {
+		panic(err)
+	}
+	return pm
+}
+
+func runInMemoryPivots(data1, data2 string, pivotLeft1, pivotLeft2 bool) string {
+	pm1 := loadPivotMapping(data1, pivotLeft1)
+	pm2 := loadPivotMapping(data2, pivotLeft2)
+	var ans strings.Builder
+	Run(pm1, pm2, func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	return ans.String()
+}
+
+func runStreaming(data1, data2 string, onItem func(mapping.Mapping)) error {
+	return runStreamingPivots(data1, data2, false, false, onItem)
+}
+
+func runStreamingPivots(data1, data2 string, pivotLeft1, pivotLeft2 bool, onItem func(mapping.Mapping)) error {
+	ps1, err := NewPivotStream(strings.NewReader(data1))
+	if err != nil {
+		return err
+	}
+	ps1.SetPivotLeft(pivotLeft1)
+	ps2, err := NewPivotStream(strings.NewReader(data2))
+	if err != nil {
+		return err
+	}
+	ps2.SetPivotLeft(pivotLeft2)
+	return RunStreaming(ps1, ps2, onItem)
+}
+
+// invertData turns a LANG -> PIVOT numeric mapping into a PIVOT -> LANG
+// one the same way 'invert' does (i.e. the result is ordered by the pivot)
+func invertData(data string) string {
+	items := make([]mapping.Mapping, 0, 100)
+	for _, line := range strings.Split(strings.TrimSpace(data), "\n") {
+		item, err := mapping.NewMappingFromString(line)
+		if err != nil {
+			panic(err)
+		}
+		items = append(items, item)
+	}
+	ch := make(chan []mapping.Mapping, 1)
+	inverted := make([]mapping.Mapping, 0, len(items))
+	mapping.Invert(items, func(item mapping.Mapping) {
+		inverted = append(inverted, item)
+	})
+	ch <- inverted
+	close(ch)
+	var ans strings.Builder
+	calign.CompressFromChan(ch, true, func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	return ans.String()
+}
+
+func TestRunStreamingSameAsRun(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 300; i++ {
+		pivotSize := 10 + rnd.Intn(300)
+		data1 := generateImportData(rnd, pivotSize)
+		data2 := generateImportData(rnd, pivotSize)
+		expected := runInMemory(data1, data2)
+		var ans strings.Builder
+		err := runStreaming(data1, data2, func(item mapping.Mapping) {
+			ans.WriteString(item.String() + "\n")
+		})
+		assert.Nil(t, err)
+		if !assert.Equal(t, expected, ans.String()) {
+			t.Logf("L1 -> P:\n%s\nL2 -> P:\n%s", data1, data2)
+			return
+		}
+	}
+}
+
+func TestRunStreamingUnorderedInput(t *testing.T) {
+	data1 := "0\t0\n2\t1\n1\t2\n"
+	data2 := "0\t0\n1\t1\n2\t2\n"
+	err := runStreaming(data1, data2, func(item mapping.Mapping) {})
+	assert.Error(t, err)
+}
+
+func TestRunStreamingBinaryInput(t *testing.T) {
+	rnd := rand.New(rand.NewSource(7))
+	data1 := generateImportData(rnd, 200)
+	data2 := generateImportData(rnd, 200)
+	expected := runInMemory(data1, data2)
+	toBinary := func(data string) string {
+		var ans strings.Builder
+		reader, err := mapping.NewReader(strings.NewReader(data))
+		assert.Nil(t, err)
+		writer, err := mapping.NewBinaryWriter(&ans, mapping.Header{})
+		assert.Nil(t, err)
+		for item, err := reader.Read(); err == nil; item, err = reader.Read() {
+			writer.Write(item)
+		}
+		writer.Close()
+		return ans.String()
+	}
+	bin1 := toBinary(data1)
+	bin2 := toBinary(data2)
+	assert.Equal(t, expected, runInMemory(bin1, bin2))
+	var ans strings.Builder
+	err := runStreaming(bin1, bin2, func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, expected, ans.String())
+}
+
+func TestRunWithPivotLeft(t *testing.T) {
+	rnd := rand.New(rand.NewSource(43))
+	for i := 0; i < 50; i++ {
+		pivotSize := 10 + rnd.Intn(300)
+		data1 := generateInvertibleData(rnd, pivotSize)
+		data2 := generateInvertibleData(rnd, pivotSize)
+		expected := runInMemory(data1, data2)
+		assert.Equal(t, expected, runInMemoryPivots(invertData(data1), data2, true, false))
+		assert.Equal(t, expected, runInMemoryPivots(data1, invertData(data2), false, true))
+		var ans strings.Builder
+		err := runStreamingPivots(invertData(data1), invertData(data2), true, true, func(item mapping.Mapping) {
+			ans.WriteString(item.String() + "\n")
+		})
+		assert.Nil(t, err)
+		if !assert.Equal(t, expected, ans.String()) {
+			t.Logf("L1 -> P:\n%s\nL2 -> P:\n%s", data1, data2)
+			return
+		}
+	}
+}
+
+func TestPivotLeftReordersRows(t *testing.T) {
+	// LANG-only rows of an inverted mapping are behind the PIVOT-only ones
+	data := "0\t0\n1\t-1\tg\n-1\t1\tg\n2\t2\n3\t-1\tg\n"
+	inverted := "0\t0\n1\t-1\tg\n-1\t1\tg\n2\t2\n-1\t3\tg\n"
+	assert.Equal(t, inverted, invertData(data))
+	assert.Equal(t, runInMemory(data, data), runInMemoryPivots(inverted, data, true, false))
+	var ans strings.Builder
+	err := runStreamingPivots(inverted, inverted, true, true, func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, runInMemory(data, data), ans.String())
+}
+
+func TestPivotLeftUnorderedInput(t *testing.T) {
+	// the LANG-only row 1 is behind the row mapping both the languages
+	data := "0\t0\n2\t2\n-1\t1\tg\n"
+	other := "0\t0\n1\t1\n2\t2\n"
+	assert.Equal(t, runInMemory("0\t0\n1\t-1\tg\n2\t2\n", other), runInMemoryPivots(data, other, true, false))
+	err := runStreamingPivots(data, other, true, false, func(item mapping.Mapping) {})
+	assert.Error(t, err)
+}
+
+func TestPivotLeftHeader(t *testing.T) {
+	ps, err := NewPivotStream(strings.NewReader("#@ corpus1=ic_cs\n#@ corpus2=ic_en\n#@ size1=3\n0\t0\n"))
+	assert.Nil(t, err)
+	ps.SetPivotLeft(true)
+	assert.Equal(t, mapping.Header{Corpus1: "ic_en", Corpus2: "ic_cs", Size2: 3}, ps.Header())
+}
+
+func TestRunSharedPivotMappings(t *testing.T) {
+	rnd := rand.New(rand.NewSource(44))
+	data := make([]string, 3)
+	pms := make([]*PivotMapping, 3)
+	for i := range data {
+		data[i] = generateImportData(rnd, 500)
+		pms[i] = loadPivotMapping(data[i], false)
+	}
+	results := make(map[[2]int]string)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for i := range pms {
+		for j := range pms {
+			if i == j {
+				continue
+			}
+			wg.Add(1)
+			go func(i, j int) {
+				defer wg.Done()
+				var ans strings.Builder
+				Run(pms[i], pms[j], func(item mapping.Mapping) {
+					ans.WriteString(item.String() + "\n")
+				})
+				mutex.Lock()
+				results[[2]int{i, j}] = ans.String()
+				mutex.Unlock()
+			}(i, j)
+		}
+	}
+	wg.Wait()
+	for pair, result := range results {
+		assert.Equal(t, runInMemory(data[pair[0]], data[pair[1]]), result)
+	}
+}
@@ -20,46 +20,90 @@
 package transalign
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"sort"
 
 	"github.com/czcorpus/ictools/mapping"
 )
 
-// fetchRow sets new language range and pivot range for provided langPos, pivotPos
-// arguments using PivotMapping data on line langIdx.
+const (
+	// PivotRight specifies a LANG -> PIVOT mapping
+	PivotRight = "right"
+
+	// PivotLeft specifies a PIVOT -> LANG mapping
+	PivotLeft = "left"
+
+	// PivotAuto specifies that the pivot column should be
+	// determined using mapping headers (see DetectPivotSides)
+	PivotAuto = "auto"
+
+	// cancelCheckInterval specifies how often (in alignment steps)
+	// RunContext checks whether it has been cancelled
+	cancelCheckInterval = 10000
+)
+
+// rowSource provides sequential access to rows of a LANG -> PIVOT
+// mapping. Rows are always accessed either at the index of the last
+// accessed row or at the next one (which makes streaming implementations
+// possible).
+type rowSource interface {
+
+	// Row returns language range, pivot range and link metadata of
+	// a row with a specified index. The last value is false if there
+	// is no such row.
+	Row(idx int) (mapping.PosRange, mapping.PosRange, mapping.LinkMeta, bool)
+
+	// HasGapAtRow tests whether a specified row represents a gap
+	HasGapAtRow(idx int) bool
+}
+
+// fetchRow sets new language range, pivot range and link metadata for provided
+// langPos, pivotPos, meta arguments using rowSource data on line langIdx.
 // It returns true in case there was a range information available at the langIdx index.
-// Otherwise (if langIdx > length of pm.ranges data), false is returned which
+// Otherwise (if langIdx > length of the data), false is returned which
 // means that the caller reached the end of data.
 func fetchRow(
 	langIdx int,
 	langPos *mapping.PosRange,
 	pivotPos *mapping.PosRange,
-	pm *PivotMapping,
+	meta *mapping.LinkMeta,
+	pm rowSource,
 ) bool {
-	if langIdx >= len(pm.ranges) {
+	rowLang, rowPivot, rowMeta, ok := pm.Row(langIdx)
+	if !ok {
 		return false
 	}
-	langPos.First = pm.ranges[langIdx].First
-	langPos.Last = pm.ranges[langIdx].Last
-	pivotPos.First = pm.pivots[langIdx].First
-	pivotPos.Last = pm.pivots[langIdx].Last
+	*langPos = rowLang
+	*pivotPos = rowPivot
+	*meta = rowMeta
 	return true
 }
 
-// appendRow extends provided langPos, pivotPos using data loaded from line langIdx
-func appendRow(langIdx int, langPos *mapping.PosRange, pivotPos *mapping.PosRange, pm *PivotMapping) {
-	if langIdx >= len(pm.ranges) {
-		return
+// appendRow extends provided langPos, pivotPos, meta using data loaded from line langIdx.
+// It returns false (and keeps the arguments untouched) in case there is no such line.
+func appendRow(
+	langIdx int,
+	langPos *mapping.PosRange,
+	pivotPos *mapping.PosRange,
+	meta *mapping.LinkMeta,
+	pm rowSource,
+) bool {
+	rowLang, rowPivot, rowMeta, ok := pm.Row(langIdx)
+	if !ok {
+		return false
 	}
+	*meta = meta.Combine(rowMeta)
 	if langPos.First == -1 {
-		langPos.First = pm.ranges[langIdx].First
+		langPos.First = rowLang.First
 	}
 
-	if pm.ranges[langIdx].Last != -1 {
-		langPos.Last = pm.ranges[langIdx].Last
+	if rowLang.Last != -1 {
+		langPos.Last = rowLang.Last
 	}
-	pivotPos.Last = pm.pivots[langIdx].Last
+	pivotPos.Last = rowPivot.Last
+	return true
 }
 
 // addMapping is a simple wrapper around 'append' for the mapping
@@ -75,15 +119,17 @@ func addMapping(list []mapping.Mapping, v mapping.Mapping) []mapping.Mapping {
 // Run implements an algorith for finding a mapping
 // between L1 and L1 based on two "half mappings"
 // L1 -> LP and L2 -> LP.
+// Link metadata of the composed rows are combined
+// (see mapping.LinkMeta.Combine).
 func Run(pivotMapping1 *PivotMapping, pivotMapping2 *PivotMapping, onItem func(mapping.Mapping)) {
-	log.Print("INFO: Computing new alignment...")
+	RunContext(context.Background(), pivotMapping1, pivotMapping2, onItem)
+}
 
-	l1Idx := 0                  // current line in L1 source
-	l1Pos := mapping.PosRange{} // current L1 range
-	p1Pos := mapping.PosRange{} // current P1 range (pivot for L1)
-	l2Idx := 0                  // current line in L2 source
-	l2Pos := mapping.PosRange{} // current L2 range
-	p2Pos := mapping.PosRange{} // current P2 range (pivot for L2)
+// RunContext works like Run but it can be cancelled via ctx
+// in which case the context error is returned and no items
+// are passed to onItem.
+func RunContext(ctx context.Context, pivotMapping1 *PivotMapping, pivotMapping2 *PivotMapping, onItem func(mapping.Mapping)) error {
+	log.Print("INFO: Computing new alignment...")
 
 	// We have to create two separate lists for the mappings as
 	// one of the [-1, x], [x, -1] mappings must be kept separate
@@ -92,115 +138,328 @@ func Run(pivotMapping1 *PivotMapping, pivotMapping2 *PivotMapping, onItem func(m
 	mapL1L2 := make([]mapping.Mapping, 0, pivotMapping1.Size())      // TODO size estimation
 	mapNoneL2 := make([]mapping.Mapping, 0, pivotMapping1.Size()/10) // 10 is just an estimate
 
-	l1FetchOK := fetchRow(l1Idx, &l1Pos, &p1Pos, pivotMapping1)
-	l2FetchOK := fetchRow(l2Idx, &l2Pos, &p2Pos, pivotMapping2)
+	align(
+		ctx,
+		pivotMapping1,
+		pivotMapping2,
+		func(item mapping.Mapping) {
+			mapL1L2 = addMapping(mapL1L2, item)
+		},
+		func(item mapping.Mapping) {
+			mapNoneL2 = addMapping(mapNoneL2, item)
+		},
+		nil,
+	)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	log.Print("INFO: Sorting L1->L2/None and None->L2 lists...")
+	done := make(chan bool, 2)
+	go func() {
+		sort.Sort(mapping.SortableMapping(mapL1L2))
+		done <- true
+	}()
+	go func() {
+		sort.Sort(mapping.SortableMapping(mapNoneL2))
+		done <- true
+	}()
+	<-done
+	<-done
+
+	log.Print("INFO: Compressing and generating output...")
+
+	mapping.MergeMappings(mapL1L2, mapNoneL2, onItem)
+	return nil
+}
+
+// align is the core of the transalign algorithm. It walks through
+// both pivot mappings and produces [a, b] + [a, -1] items (via onL1L2)
+// and [-1, b] items (via onNoneL2). Please note that the items are not
+// sorted and they may also contain [-1, -1] items which should be ignored.
+// One-sided items caused by a gap in any of the pivot mappings are
+// marked as gaps (so the result can be used as an input of another
+// composition; see Compose). Once one of the pivot mappings is exhausted,
+// the remaining rows of the other one are emitted as unaligned gap items.
+// The optional onStep function is called after each step with current
+// L1 and L2 ranges. In case ctx is cancelled, the function stops without
+// processing the remaining rows (the caller is expected to check ctx).
+func align(
+	ctx context.Context,
+	pivotMapping1 rowSource,
+	pivotMapping2 rowSource,
+	onL1L2 func(mapping.Mapping),
+	onNoneL2 func(mapping.Mapping),
+	onStep func(l1Pos, l2Pos mapping.PosRange),
+) {
+
+	l1Idx := 0                   // current line in L1 source
+	l1Pos := mapping.PosRange{}  // current L1 range
+	p1Pos := mapping.PosRange{}  // current P1 range (pivot for L1)
+	l1Meta := mapping.LinkMeta{} // current L1-P1 link metadata
+	l2Idx := 0                   // current line in L2 source
+	l2Pos := mapping.PosRange{}  // current L2 range
+	p2Pos := mapping.PosRange{}  // current P2 range (pivot for L2)
+	l2Meta := mapping.LinkMeta{} // current L2-P2 link metadata
+
+	l1FetchOK := fetchRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1)
+	l2FetchOK := fetchRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2)
+
+	// emitAligned emits current L1 and L2 ranges sharing the same pivot range
+	emitAligned := func() {
+		if l1Pos.First != -1 {
+			onL1L2(mapping.Mapping{
+				From: l1Pos,
+				To:   l2Pos,
+				Meta: l1Meta.Combine(l2Meta),
+			})
+
+		} else {
+			onNoneL2(mapping.Mapping{
+				From:  l1Pos,
+				To:    l2Pos,
+				IsGap: pivotMapping1.HasGapAtRow(l1Idx),
+				Meta:  l1Meta.Combine(l2Meta),
+			})
+		}
+	}
+
+	// cancelled tests (once per cancelCheckInterval steps) whether ctx is done
+	steps := 0
+	cancelled := func() bool {
+		steps++
+		return steps%cancelCheckInterval == 0 && ctx.Err() != nil
+	}
 
 	//for l1Idx < pivotMapping1.Size() || l2Idx < pivotMapping2.Size() {
 	for l1FetchOK && l2FetchOK {
+		if cancelled() {
+			return
+		}
 		if p1Pos.First < p2Pos.First { // must align beginning of pivots
 			if p1Pos.Last == -1 {
-				mapL1L2 = addMapping(mapL1L2, mapping.Mapping{
-					From: l1Pos,
-					To:   mapping.NewEmptyPosRange(),
+				onL1L2(mapping.Mapping{
+					From:  l1Pos,
+					To:    mapping.NewEmptyPosRange(),
+					IsGap: pivotMapping1.HasGapAtRow(l1Idx),
+					Meta:  l1Meta,
 				})
 			}
 			l1Idx++
-			l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, pivotMapping1)
+			l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1)
 
 		} else if p1Pos.First > p2Pos.First { // must align beginning of pivots
 			if p2Pos.Last == -1 {
-				mapNoneL2 = addMapping(mapNoneL2, mapping.Mapping{
-					From: mapping.NewEmptyPosRange(),
-					To:   l2Pos,
+				onNoneL2(mapping.Mapping{
+					From:  mapping.NewEmptyPosRange(),
+					To:    l2Pos,
+					IsGap: pivotMapping2.HasGapAtRow(l2Idx),
+					Meta:  l2Meta,
 				})
 			}
 			l2Idx++
-			l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, pivotMapping2)
+			l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2)
 
 		} else { // pivots start at the same position; now try to align end positions
 			if p1Pos.Last > p2Pos.Last {
 				if pivotMapping1.HasGapAtRow(l1Idx) { // we cannot extend alignment across a gap
-					mapNoneL2 = addMapping(mapNoneL2, mapping.Mapping{
-						From: mapping.NewEmptyPosRange(),
-						To:   l2Pos,
+					onNoneL2(mapping.Mapping{
+						From:  mapping.NewEmptyPosRange(),
+						To:    l2Pos,
+						IsGap: true,
+						Meta:  l2Meta,
 					})
 					l2Idx++
-					l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, pivotMapping2)
+					l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2)
 					// a correction to keep pivots aligned (a spec. situation)
 					// but we're losing compression here (TODO improve)
 					p1Pos.First = p2Pos.First
 
 				} else {
 					l2Idx++
-					appendRow(l2Idx, &l2Pos, &p2Pos, pivotMapping2)
+					if !appendRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2) {
+						// L2 source ended within the pivot range (which happens
+						// only if pivot sizes differ) so the ranges are aligned as they are
+						emitAligned()
+						l1Idx++
+						l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1)
+						l2FetchOK = false
+					}
 				}
 
 			} else if p2Pos.Last > p1Pos.Last {
 				if pivotMapping2.HasGapAtRow(l2Idx) {
-					mapL1L2 = addMapping(mapL1L2, mapping.Mapping{
-						From: l1Pos,
-						To:   mapping.NewEmptyPosRange(),
+					onL1L2(mapping.Mapping{
+						From:  l1Pos,
+						To:    mapping.NewEmptyPosRange(),
+						IsGap: true,
+						Meta:  l1Meta,
 					})
 					l1Idx++
-					l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, pivotMapping1)
+					l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1)
 					p2Pos.First = p1Pos.First // a correction to keep pivots aligned (a spec. situation)
 
 				} else {
 					l1Idx++
-					appendRow(l1Idx, &l1Pos, &p1Pos, pivotMapping1)
+					if !appendRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1) {
+						// L1 source ended within the pivot range
+						emitAligned()
+						l2Idx++
+						l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2)
+						l1FetchOK = false
+					}
 				}
 
 			} else if p1Pos.Last == -1 && p2Pos.Last == -1 {
-				mapL1L2 = addMapping(mapL1L2, mapping.Mapping{
-					From: l1Pos,
-					To:   mapping.NewEmptyPosRange(),
+				onL1L2(mapping.Mapping{
+					From:  l1Pos,
+					To:    mapping.NewEmptyPosRange(),
+					IsGap: pivotMapping1.HasGapAtRow(l1Idx),
+					Meta:  l1Meta,
 				})
-				mapNoneL2 = addMapping(mapNoneL2, mapping.Mapping{
-					From: mapping.NewEmptyPosRange(),
-					To:   l2Pos,
+				onNoneL2(mapping.Mapping{
+					From:  mapping.NewEmptyPosRange(),
+					To:    l2Pos,
+					IsGap: pivotMapping2.HasGapAtRow(l2Idx),
+					Meta:  l2Meta,
 				})
 				l1Idx++
-				l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, pivotMapping1)
+				l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1)
 				l2Idx++
-				l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, pivotMapping2)
+				l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2)
 
 			} else {
-				if l1Pos.First != -1 {
-					mapL1L2 = addMapping(mapL1L2, mapping.Mapping{
-						From: l1Pos,
-						To:   l2Pos,
-					})
-
-				} else {
-					mapNoneL2 = addMapping(mapNoneL2, mapping.Mapping{
-						From: l1Pos,
-						To:   l2Pos,
-					})
-				}
+				emitAligned()
 				l1Idx++
-				l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, pivotMapping1)
+				l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1)
 				l2Idx++
-				l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, pivotMapping2)
+				l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2)
 
 			}
 		}
+		if onStep != nil {
+			onStep(l1Pos, l2Pos)
+		}
 	}
 
-	log.Print("INFO: Sorting L1->L2/None and None->L2 lists...")
-	done := make(chan bool, 2)
-	go func() {
-		sort.Sort(mapping.SortableMapping(mapL1L2))
-		done <- true
-	}()
-	go func() {
-		sort.Sort(mapping.SortableMapping(mapNoneL2))
-		done <- true
-	}()
-	<-done
-	<-done
+	// one of the sources is exhausted so the remaining rows
+	// of the other one cannot be aligned to anything
+	for l1FetchOK {
+		if cancelled() {
+			return
+		}
+		onL1L2(mapping.Mapping{
+			From:  l1Pos,
+			To:    mapping.NewEmptyPosRange(),
+			IsGap: p1Pos.First != -1 || pivotMapping1.HasGapAtRow(l1Idx),
+			Meta:  l1Meta,
+		})
+		l1Idx++
+		l1FetchOK = fetchRow(l1Idx, &l1Pos, &p1Pos, &l1Meta, pivotMapping1)
+		if onStep != nil {
+			onStep(l1Pos, mapping.NewEmptyPosRange())
+		}
+	}
+	for l2FetchOK {
+		if cancelled() {
+			return
+		}
+		onNoneL2(mapping.Mapping{
+			From:  mapping.NewEmptyPosRange(),
+			To:    l2Pos,
+			IsGap: p2Pos.First != -1 || pivotMapping2.HasGapAtRow(l2Idx),
+			Meta:  l2Meta,
+		})
+		l2Idx++
+		l2FetchOK = fetchRow(l2Idx, &l2Pos, &p2Pos, &l2Meta, pivotMapping2)
+		if onStep != nil {
+			onStep(mapping.NewEmptyPosRange(), l2Pos)
+		}
+	}
+}
 
-	log.Print("INFO: Compressing and generating output...")
+// VerifyHeaders tests whether LANG1 -> PIVOT and LANG2 -> PIVOT mappings
+// have been created for the same pivot corpus and structure attribute
+// (only information available in both the headers is compared).
+func VerifyHeaders(h1, h2 mapping.Header) error {
+	return h2.Verify(mapping.Header{Corpus2: h1.Corpus2, Attr: h1.Attr, Size2: h1.Size2})
+}
 
-	mapping.MergeMappings(mapL1L2, mapNoneL2, onItem)
+// CreateHeader derives a header of LANG1 -> LANG2 mapping
+// from headers of LANG1 -> PIVOT and LANG2 -> PIVOT mappings
+func CreateHeader(h1, h2 mapping.Header) mapping.Header {
+	ans := mapping.Header{
+		Corpus1: h1.Corpus1,
+		Corpus2: h2.Corpus1,
+		Attr:    h1.Attr,
+		Size1:   h1.Size1,
+		Size2:   h2.Size1,
+	}
+	if ans.Attr == "" {
+		ans.Attr = h2.Attr
+	}
+	return ans
+}
 
+// DetectPivotSides determines for both the input mappings whether
+// the pivot language is in the left column. The pivot corpus is the one
+// both mapping headers share. In case the headers do not contain corpora
+// names (or if the right columns match), pivots are expected to be in
+// the right column (LANG -> PIVOT). An error is returned if there is
+// no shared corpus or if the pivot cannot be determined unambiguously.
+func DetectPivotSides(h1, h2 mapping.Header) (bool, bool, error) {
+	if h1.Corpus1 == "" || h1.Corpus2 == "" || h2.Corpus1 == "" || h2.Corpus2 == "" ||
+		h1.Corpus2 == h2.Corpus2 {
+		return false, false, nil
+	}
+	var pivotLeft1, pivotLeft2 bool
+	numFound := 0
+	for _, left1 := range []bool{false, true} {
+		for _, left2 := range []bool{false, true} {
+			pivot1 := h1.Corpus2
+			if left1 {
+				pivot1 = h1.Corpus1
+			}
+			pivot2 := h2.Corpus2
+			if left2 {
+				pivot2 = h2.Corpus1
+			}
+			if pivot1 == pivot2 {
+				pivotLeft1, pivotLeft2 = left1, left2
+				numFound++
+			}
+		}
+	}
+	if numFound == 0 {
+		return false, false, fmt.Errorf("Mappings %s -> %s and %s -> %s have no common (pivot) corpus",
+			h1.Corpus1, h1.Corpus2, h2.Corpus1, h2.Corpus2)
+
+	} else if numFound > 1 {
+		return false, false, fmt.Errorf("Cannot determine pivot corpus of mappings %s -> %s and %s -> %s",
+			h1.Corpus1, h1.Corpus2, h2.Corpus1, h2.Corpus2)
+	}
+	return pivotLeft1, pivotLeft2, nil
+}
+
+// DetectPivotSidesAll works like DetectPivotSides but for any number
+// (at least two) of mappings against a common pivot. Each mapping
+// is compared with the first one.
+func DetectPivotSidesAll(headers []mapping.Header) ([]bool, error) {
+	if len(headers) < 2 {
+		return nil, fmt.Errorf("At least two mappings are required")
+	}
+	ans := make([]bool, len(headers))
+	for i := 1; i < len(headers); i++ {
+		left0, left, err := DetectPivotSides(headers[0], headers[i])
+		if err != nil {
+			return nil, err
+		}
+		if i > 1 && left0 != ans[0] {
+			return nil, fmt.Errorf("Cannot determine pivot corpus of mapping %s -> %s",
+				headers[0].Corpus1, headers[0].Corpus2)
+		}
+		ans[0] = left0
+		ans[i] = left
+	}
+	return ans, nil
 }
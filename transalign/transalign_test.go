@@ -0,0 +1,181 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package transalign
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func loadTestPivotMapping(name string) *PivotMapping {
+	cwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	f, err := os.Open(filepath.Join(cwd, "..", "testdata", name))
+	if err != nil {
+		panic(err)
+	}
+	pm, err := NewPivotMapping(f)
+	if err != nil {
+		panic(err)
+	}
+	if err := pm.Load(); err != nil {
+		panic(err)
+	}
+	return pm
+}
+
+func TestRunCombinesMeta(t *testing.T) {
+	pm1 := loadTestPivotMapping("meta1.txt")
+	pm2 := loadTestPivotMapping("meta2.txt")
+	assert.True(t, pm1.HasGapAtRow(3))
+	assert.Equal(t, mapping.StatusAuto, pm1.MetaAtRow(1).Status)
+
+	ans := make([]mapping.Mapping, 0, 4)
+	Run(pm1, pm2, func(item mapping.Mapping) {
+		ans = append(ans, item)
+	})
+	assert.Equal(t, 4, len(ans))
+	assert.Equal(t, "0\t0\t\tman", ans[0].String())
+	assert.Equal(t, "1\t1,2\t\tauto:0.5", ans[1].String())
+	assert.Equal(t, "2\t3\t\tauto:0.9", ans[2].String())
+	assert.Equal(t, "3\t-1\tg", ans[3].String())
+}
+
+func runStrings(data1, data2 string) string {
+	var ans strings.Builder
+	Run(loadPivotMapping(data1, false), loadPivotMapping(data2, false), func(item mapping.Mapping) {
+		ans.WriteString(item.String() + "\n")
+	})
+	return ans.String()
+}
+
+func TestRunKeepsTrailingRows(t *testing.T) {
+	assert.Equal(
+		t,
+		"0\t0\n1\t-1\tg\n2\t-1\tg\n-1\t1\tg\n",
+		runStrings("0\t0\n1\t-1\tg\n2\t-1\tg\n", "0\t0\n1\t-1\tg\n"),
+	)
+	assert.Equal(t, "0\t0\n1\t1\n2\t-1\tg\n", runStrings("0\t0\n1\t1\n2\t2\n", "0\t0\n1\t1\n"))
+}
+
+func TestRunSourceEndsWithinPivotRange(t *testing.T) {
+	assert.Equal(t, "0\t0\n", runStrings("0\t0,1\n", "0\t0\n"))
+	assert.Equal(t, "0,1\t0\n", runStrings("0\t0\n1\t1\n", "0\t0,1\n"))
+}
+
+func TestRunContextCancelled(t *testing.T) {
+	// all the rows of the second mapping are reached via range extension
+	size := 3 * cancelCheckInterval
+	var data2 strings.Builder
+	for i := 0; i < size; i++ {
+		data2.WriteString(fmt.Sprintf("%d\t%d\n", i, i))
+	}
+	pm1 := loadPivotMapping(fmt.Sprintf("0\t0,%d\n", size-1), false)
+	pm2 := loadPivotMapping(data2.String(), false)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	numItems := 0
+	err := RunContext(ctx, pm1, pm2, func(item mapping.Mapping) {
+		numItems++
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, numItems)
+}
+
+func TestVerifyHeaders(t *testing.T) {
+	h1 := mapping.Header{Corpus1: "ic_pl", Corpus2: "ic_cs", Attr: "s.id", Size1: 10, Size2: 20}
+	h2 := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_cs", Attr: "s.id", Size1: 15, Size2: 20}
+	assert.Nil(t, VerifyHeaders(h1, h2))
+	assert.Nil(t, VerifyHeaders(h1, mapping.Header{}))
+	h2.Size2 = 21
+	assert.Error(t, VerifyHeaders(h1, h2))
+	h2.Size2 = 20
+	h2.Corpus2 = "ic_de"
+	assert.Error(t, VerifyHeaders(h1, h2))
+}
+
+func TestCreateHeader(t *testing.T) {
+	h1 := mapping.Header{Corpus1: "ic_pl", Corpus2: "ic_cs", Attr: "s.id", Size1: 10, Size2: 20}
+	h2 := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_cs", Attr: "s.id", Size1: 15, Size2: 20}
+	assert.Equal(
+		t,
+		mapping.Header{Corpus1: "ic_pl", Corpus2: "ic_en", Attr: "s.id", Size1: 10, Size2: 15},
+		CreateHeader(h1, h2),
+	)
+}
+
+func TestDetectPivotSides(t *testing.T) {
+	cs2en := mapping.Header{Corpus1: "ic_cs", Corpus2: "ic_en"}
+	pl2en := mapping.Header{Corpus1: "ic_pl", Corpus2: "ic_en"}
+	en2pl := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_pl"}
+	en2de := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_de"}
+
+	left1, left2, err := DetectPivotSides(cs2en, pl2en)
+	assert.Nil(t, err)
+	assert.False(t, left1)
+	assert.False(t, left2)
+
+	left1, left2, err = DetectPivotSides(cs2en, en2pl)
+	assert.Nil(t, err)
+	assert.False(t, left1)
+	assert.True(t, left2)
+
+	left1, left2, err = DetectPivotSides(en2pl, en2de)
+	assert.Nil(t, err)
+	assert.True(t, left1)
+	assert.True(t, left2)
+
+	left1, left2, err = DetectPivotSides(mapping.Header{}, en2pl)
+	assert.Nil(t, err)
+	assert.False(t, left1)
+	assert.False(t, left2)
+
+	_, _, err = DetectPivotSides(cs2en, mapping.Header{Corpus1: "ic_pl", Corpus2: "ic_de"})
+	assert.Error(t, err)
+
+	_, _, err = DetectPivotSides(pl2en, en2pl)
+	assert.Error(t, err)
+}
+
+func TestDetectPivotSidesAll(t *testing.T) {
+	cs2en := mapping.Header{Corpus1: "ic_cs", Corpus2: "ic_en"}
+	en2pl := mapping.Header{Corpus1: "ic_en", Corpus2: "ic_pl"}
+	de2en := mapping.Header{Corpus1: "ic_de", Corpus2: "ic_en"}
+
+	ans, err := DetectPivotSidesAll([]mapping.Header{cs2en, en2pl, de2en})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, true, false}, ans)
+
+	ans, err = DetectPivotSidesAll([]mapping.Header{{}, {}, {}})
+	assert.Nil(t, err)
+	assert.Equal(t, []bool{false, false, false}, ans)
+
+	_, err = DetectPivotSidesAll([]mapping.Header{cs2en, de2en, mapping.Header{Corpus1: "ic_cs", Corpus2: "ic_de"}})
+	assert.Error(t, err)
+
+	_, err = DetectPivotSidesAll([]mapping.Header{cs2en})
+	assert.Error(t, err)
+}
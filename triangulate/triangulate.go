@@ -0,0 +1,226 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package triangulate provides functions to check consistency
+// of a direct LANG1 -> LANG2 alignment with an alignment obtained
+// via a pivot language (see transalign).
+package triangulate
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/diff"
+	"github.com/czcorpus/ictools/export"
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// Agreement contains numbers of links of a direct and a pivoted
+// alignment (either of a whole alignment or of a single document).
+// Unaligned structures are counted as individual 1-0 (0-1) links.
+type Agreement struct {
+	Doc        string
+	NumDirect  int
+	NumPivoted int
+	NumAgreed  int
+}
+
+// Rate returns a ratio of links found in both the alignments
+// to all the distinct links found in any of them
+func (a *Agreement) Rate() float64 {
+	total := a.NumDirect + a.NumPivoted - a.NumAgreed
+	if total == 0 {
+		return 1
+	}
+	return float64(a.NumAgreed) / float64(total)
+}
+
+// Result contains agreement of the compared alignments along
+// with disagreeing segments (sequences of links found only in one
+// of the alignments without any agreeing link between them).
+type Result struct {
+	Total     Agreement
+	Documents []*Agreement
+	Segments  []*diff.Hunk
+
+	// describe provides a description of a link (e.g. using
+	// structure IDs) appended to written links
+	describe func(item mapping.Mapping) string
+}
+
+// WriteText writes the result in a human readable form. Disagreeing
+// segments are written first (links of the direct alignment marked
+// with '<', links of the pivoted one with '>'), followed by agreement
+// rates of individual documents and the total one.
+func (r *Result) WriteText(w io.Writer) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	for _, segment := range r.Segments {
+		if segment.Doc != "" {
+			printf("@@ %s @@ %s\n", segment.Type(), segment.Doc)
+
+		} else {
+			printf("@@ %s @@\n", segment.Type())
+		}
+		for _, item := range segment.Removed {
+			printf("<%s\t%s%s\n", item.From, item.To, r.describe(item))
+		}
+		for _, item := range segment.Added {
+			printf(">%s\t%s%s\n", item.From, item.To, r.describe(item))
+		}
+	}
+	printAgreement := func(name string, agr *Agreement) {
+		printf("# %s: %.2f %% (%d agreeing links, %d direct, %d pivoted)\n", name, agr.Rate()*100,
+			agr.NumAgreed, agr.NumDirect, agr.NumPivoted)
+	}
+	for _, doc := range r.Documents {
+		printAgreement(doc.Doc, doc)
+	}
+	printf("# segments: %d\n", len(r.Segments))
+	printAgreement("total", &r.Total)
+	return err
+}
+
+// ----------------------------------------------
+
+// Checker compares a direct alignment with a pivoted one. Items
+// of both alignments are expected to be added in the order
+// of their source data. Link metadata (gap flags, statuses)
+// are ignored.
+type Checker struct {
+	attr1  attrib.PosAttr
+	attr2  attrib.PosAttr
+	filter export.GroupFilter
+	diff   *diff.Diff
+	docs   map[string]*Agreement
+	result *Result
+}
+
+func (c *Checker) doc(ident string) *Agreement {
+	ans, ok := c.docs[ident]
+	if !ok {
+		ans = &Agreement{Doc: ident}
+		c.docs[ident] = ans
+		c.result.Documents = append(c.result.Documents, ans)
+	}
+	return ans
+}
+
+// countLinks calls fn for each link of an item (with a document ID
+// in case documents can be identified). Ranges of unaligned structures
+// are counted as individual links.
+func (c *Checker) countLinks(item mapping.Mapping, fn func(agr *Agreement)) {
+	if item.IsError() || item.IsEmpty() {
+		return
+	}
+	if c.filter == nil {
+		if item.From.First == -1 {
+			for i := item.To.First; i <= item.To.Last; i++ {
+				fn(&c.result.Total)
+			}
+
+		} else if item.To.First == -1 {
+			for i := item.From.First; i <= item.From.Last; i++ {
+				fn(&c.result.Total)
+			}
+
+		} else {
+			fn(&c.result.Total)
+		}
+		return
+	}
+	if item.From.First == -1 {
+		for i := item.To.First; i <= item.To.Last; i++ {
+			fn(&c.result.Total)
+			fn(c.doc(c.filter.ExtractGroupID(c.attr2.ID2Str(i))))
+		}
+
+	} else if item.To.First == -1 {
+		for i := item.From.First; i <= item.From.Last; i++ {
+			fn(&c.result.Total)
+			fn(c.doc(c.filter.ExtractGroupID(c.attr1.ID2Str(i))))
+		}
+
+	} else {
+		fn(&c.result.Total)
+		fn(c.doc(c.filter.ExtractGroupID(c.attr1.ID2Str(item.From.First))))
+	}
+}
+
+// AddDirect adds an item of the direct alignment
+func (c *Checker) AddDirect(item mapping.Mapping) {
+	c.diff.AddOld(item)
+	c.countLinks(item, func(agr *Agreement) {
+		agr.NumDirect++
+	})
+}
+
+// AddPivoted adds an item of the pivoted alignment
+func (c *Checker) AddPivoted(item mapping.Mapping) {
+	c.diff.AddNew(item)
+	c.countLinks(item, func(agr *Agreement) {
+		agr.NumPivoted++
+	})
+}
+
+// Result compares both the alignments and returns the result.
+// The method is expected to be called once all the items are added.
+func (c *Checker) Result() *Result {
+	segments, _ := c.diff.Hunks()
+	c.result.Segments = segments
+	numDisagreed := 0
+	docDisagreed := make(map[string]int)
+	for _, segment := range segments {
+		numDisagreed += len(segment.Removed)
+		docDisagreed[segment.Doc] += len(segment.Removed)
+	}
+	c.result.Total.NumAgreed = c.result.Total.NumDirect - numDisagreed
+	for _, doc := range c.result.Documents {
+		doc.NumAgreed = doc.NumDirect - docDisagreed[doc.Doc]
+	}
+	return c.result
+}
+
+func (c *Checker) describe(item mapping.Mapping) string {
+	if c.attr1 == nil || c.attr2 == nil {
+		return ""
+	}
+	return "\t# " + item.From.Describe(c.attr1) + " -> " + item.To.Describe(c.attr2)
+}
+
+// NewChecker creates a new Checker instance. Structure string IDs
+// (attr1, attr2) are optional (nil) and used to describe disagreeing
+// links. In case a group filter is provided (along with the IDs),
+// agreement of individual documents is calculated too.
+func NewChecker(attr1, attr2 attrib.PosAttr, filter export.GroupFilter) *Checker {
+	if attr1 == nil || attr2 == nil {
+		filter = nil
+	}
+	ans := &Checker{
+		attr1:  attr1,
+		attr2:  attr2,
+		filter: filter,
+		diff:   diff.NewDiff(attr1, attr2, filter),
+		docs:   make(map[string]*Agreement),
+	}
+	ans.result = &Result{describe: ans.describe}
+	return ans
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package triangulate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/czcorpus/ictools/diff"
+	"github.com/czcorpus/ictools/export"
+	"github.com/stretchr/testify/assert"
+)
+
+func runChecker(direct, pivoted string, attr1, attr2 attrib.PosAttr, filter export.GroupFilter) *Result {
+	c := NewChecker(attr1, attr2, filter)
+	diff.ReadNumeric(strings.NewReader(direct), c.AddDirect)
+	diff.ReadNumeric(strings.NewReader(pivoted), c.AddPivoted)
+	return c.Result()
+}
+
+func TestFullAgreement(t *testing.T) {
+	ans := runChecker("0\t0\n-1\t1,2\n1\t3\n", "0\t0\n-1\t1\n-1\t2\n1\t3\tg\n", nil, nil, nil)
+	assert.Equal(t, 0, len(ans.Segments))
+	assert.Equal(t, 4, ans.Total.NumAgreed)
+	assert.Equal(t, 1.0, ans.Total.Rate())
+	assert.Equal(t, 0, len(ans.Documents))
+}
+
+func TestAgreementWithoutDocuments(t *testing.T) {
+	ans := runChecker("0\t0\n1\t1\n2\t2\n", "0\t0\n1\t-1\n-1\t1\n2\t2\n", nil, nil, nil)
+	assert.Equal(t, 1, len(ans.Segments))
+	assert.Equal(t, diff.HunkChanged, ans.Segments[0].Type())
+	assert.Equal(t, Agreement{NumDirect: 3, NumPivoted: 4, NumAgreed: 2}, ans.Total)
+	assert.InDelta(t, 0.4, ans.Total.Rate(), 0.0001)
+}
+
+func TestAgreementPerDocument(t *testing.T) {
+	attr1 := &attribtest.ListAttr{IDs: []string{"pl:doc1:0:1:1", "pl:doc1:0:1:2", "pl:doc2:0:1:1"}}
+	attr2 := &attribtest.ListAttr{IDs: []string{"en:doc1:0:1:1", "en:doc1:0:1:2", "en:doc2:0:1:1"}}
+	ans := runChecker(
+		"0\t0\n1\t1\n2\t2\n",
+		"0\t0\n1\t-1\n-1\t1\n2\t2\n",
+		attr1, attr2, export.NewGroupFilter(export.ExportTypeIntercorp),
+	)
+	assert.Equal(t, 2, len(ans.Documents))
+	assert.Equal(t, Agreement{Doc: "doc1", NumDirect: 2, NumPivoted: 3, NumAgreed: 1}, *ans.Documents[0])
+	assert.InDelta(t, 0.25, ans.Documents[0].Rate(), 0.0001)
+	assert.Equal(t, Agreement{Doc: "doc2", NumDirect: 1, NumPivoted: 1, NumAgreed: 1}, *ans.Documents[1])
+	assert.Equal(t, 1, len(ans.Segments))
+	assert.Equal(t, "doc1", ans.Segments[0].Doc)
+
+	var buff bytes.Buffer
+	assert.Nil(t, ans.WriteText(&buff))
+	lines := strings.Split(strings.TrimSpace(buff.String()), "\n")
+	assert.Equal(t, []string{
+		"@@ changed @@ doc1",
+		"<1\t1\t# pl:doc1:0:1:2 -> en:doc1:0:1:2",
+		">1\t-1\t# pl:doc1:0:1:2 -> -",
+		">-1\t1\t# - -> en:doc1:0:1:2",
+		"# doc1: 25.00 % (1 agreeing links, 2 direct, 3 pivoted)",
+		"# doc2: 100.00 % (1 agreeing links, 1 direct, 1 pivoted)",
+		"# segments: 1",
+		"# total: 40.00 % (2 agreeing links, 3 direct, 4 pivoted)",
+	}, lines)
+}
@@ -0,0 +1,127 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package validate
+
+import (
+	"io"
+	"strings"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/export"
+	"github.com/czcorpus/ictools/mapping"
+)
+
+const (
+	// DefaultMaxRangeSize is a default size of a range
+	// considered suspiciously long
+	DefaultMaxRangeSize = 100
+)
+
+// CrossingDetector searches for aligned items which cross document
+// boundaries. Document IDs are extracted from structure IDs using
+// an export.GroupFilter. The following items are reported:
+//
+// * items with a range (on any side) containing structures of different documents,
+//
+// * items with sides belonging to different documents,
+//
+// * items with a range longer than MaxRangeSize structures.
+//
+// Only two-sided items are checked as one-sided ones (unaligned
+// structures) are compressed regardless of document boundaries.
+type CrossingDetector struct {
+	reporter
+	attr1    attrib.PosAttr
+	attr2    attrib.PosAttr
+	filter   export.GroupFilter
+	numItems int
+
+	// MaxRangeSize specifies a range size considered suspicious
+	// (0 = no limit)
+	MaxRangeSize int
+}
+
+// rangeDocs returns IDs of all the documents found
+// within a range (in the order of appearance)
+func (cd *CrossingDetector) rangeDocs(rng mapping.PosRange, attr attrib.PosAttr) []string {
+	ans := make([]string, 0, 2)
+	for i := rng.First; i <= rng.Last; i++ {
+		doc := cd.filter.ExtractGroupID(attr.ID2Str(i))
+		if doc != "" && (len(ans) == 0 || ans[len(ans)-1] != doc) {
+			ans = append(ans, doc)
+		}
+	}
+	return ans
+}
+
+func (cd *CrossingDetector) describe(item *mapping.Mapping) string {
+	return "[" + item.From.String() + " -> " + item.To.String() + "] (" +
+		cd.attr1.ID2Str(item.From.First) + ".." + cd.attr1.ID2Str(item.From.Last) + " -> " +
+		cd.attr2.ID2Str(item.To.First) + ".." + cd.attr2.ID2Str(item.To.Last) + ")"
+}
+
+// Check checks a single item of the alignment
+func (cd *CrossingDetector) Check(line int, item mapping.Mapping) {
+	cd.numItems++
+	if item.IsError() || item.From.First == -1 || item.To.First == -1 {
+		return
+	}
+	docs1 := cd.rangeDocs(item.From, cd.attr1)
+	docs2 := cd.rangeDocs(item.To, cd.attr2)
+	if len(docs1) > 1 {
+		cd.report(line, "LANG1 range crosses documents %s: %s", strings.Join(docs1, ", "), cd.describe(&item))
+	}
+	if len(docs2) > 1 {
+		cd.report(line, "LANG2 range crosses documents %s: %s", strings.Join(docs2, ", "), cd.describe(&item))
+	}
+	if len(docs1) == 1 && len(docs2) == 1 && docs1[0] != docs2[0] {
+		cd.report(line, "sides belong to different documents (%s, %s): %s", docs1[0], docs2[0], cd.describe(&item))
+	}
+	size1 := item.From.Last - item.From.First + 1
+	size2 := item.To.Last - item.To.First + 1
+	if cd.MaxRangeSize > 0 && (size1 > cd.MaxRangeSize || size2 > cd.MaxRangeSize) {
+		cd.report(line, "suspiciously long range (%d -> %d structures): %s", size1, size2, cd.describe(&item))
+	}
+}
+
+// NumItems returns a number of checked items
+func (cd *CrossingDetector) NumItems() int {
+	return cd.numItems
+}
+
+// Run reads a numeric alignment (text or binary) from src and
+// checks all its items. Found problems are passed to the onProblem
+// function provided to NewCrossingDetector.
+func (cd *CrossingDetector) Run(src io.Reader) error {
+	reader, err := mapping.NewReader(src)
+	if err != nil {
+		cd.report(0, "failed to read data: %s", err)
+		return err
+	}
+	return cd.forEachItem(reader, cd.Check)
+}
+
+// NewCrossingDetector creates a new CrossingDetector instance
+func NewCrossingDetector(attr1, attr2 attrib.PosAttr, filter export.GroupFilter, onProblem func(p Problem)) *CrossingDetector {
+	return &CrossingDetector{
+		reporter:     reporter{onProblem: onProblem},
+		attr1:        attr1,
+		attr2:        attr2,
+		filter:       filter,
+		MaxRangeSize: DefaultMaxRangeSize,
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package validate
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/czcorpus/ictools/export"
+	"github.com/stretchr/testify/assert"
+)
+
+func findCrossings(data string, maxRangeSize int) []Problem {
+	attr1 := &attribtest.ListAttr{IDs: []string{"cs:doc1:0:1:1", "cs:doc1:0:1:2", "cs:doc2:0:1:1", "cs:doc2:0:1:2"}}
+	attr2 := &attribtest.ListAttr{IDs: []string{"en:doc1:0:1:1", "en:doc2:0:1:1", "en:doc2:0:1:2", "en:doc3:0:1:1"}}
+	ans := make([]Problem, 0, 10)
+	cd := NewCrossingDetector(attr1, attr2, export.NewGroupFilter(export.ExportTypeIntercorp), func(p Problem) {
+		ans = append(ans, p)
+	})
+	cd.MaxRangeSize = maxRangeSize
+	cd.Run(strings.NewReader(data))
+	return ans
+}
+
+func TestNoCrossings(t *testing.T) {
+	problems := findCrossings("0,1\t0\n2\t1\n3\t2\n-1\t3\n", 0)
+	assert.Equal(t, 0, len(problems))
+}
+
+func TestOneSidedRangesIgnored(t *testing.T) {
+	problems := findCrossings("0,3\t-1\n-1\t0,3\n", 0)
+	assert.Equal(t, 0, len(problems))
+}
+
+func TestRangeCrossesDocuments(t *testing.T) {
+	problems := findCrossings("0\t0\n1,2\t1\n3\t2,3\n", 0)
+	assert.Equal(t, 2, len(problems))
+	assert.Equal(t, 2, problems[0].Line)
+	assert.Equal(t, "LANG1 range crosses documents doc1, doc2: [1,2 -> 1] (cs:doc1:0:1:2..cs:doc2:0:1:1 -> en:doc2:0:1:1..en:doc2:0:1:1)", problems[0].Message)
+	assert.Equal(t, 3, problems[1].Line)
+	assert.True(t, strings.HasPrefix(problems[1].Message, "LANG2 range crosses documents doc2, doc3"))
+}
+
+func TestNonCorrespondingDocuments(t *testing.T) {
+	problems := findCrossings("0\t0\n1\t1\n", 0)
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, 2, problems[0].Line)
+	assert.True(t, strings.HasPrefix(problems[0].Message, "sides belong to different documents (doc1, doc2)"))
+}
+
+func TestLongRange(t *testing.T) {
+	problems := findCrossings("0,1\t0\n", 1)
+	assert.Equal(t, 1, len(problems))
+	assert.True(t, strings.HasPrefix(problems[0].Message, "suspiciously long range (2 -> 1 structures)"))
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+// Package validate provides functions to check a numeric
+// alignment against both aligned corpora.
+package validate
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/czcorpus/ictools/attrib"
+	"github.com/czcorpus/ictools/mapping"
+)
+
+// Problem describes a single problem found in a numeric alignment.
+// Problems not related to a specific line have Line = 0.
+type Problem struct {
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line > 0 {
+		return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+	}
+	return p.Message
+}
+
+// reporter collects problems and passes them to a handler function
+type reporter struct {
+	onProblem func(p Problem)
+	numProbs  int
+}
+
+func (r *reporter) report(line int, msg string, args ...interface{}) {
+	r.numProbs++
+	r.onProblem(Problem{Line: line, Message: fmt.Sprintf(msg, args...)})
+}
+
+// NumProblems returns a number of problems found so far
+func (r *reporter) NumProblems() int {
+	return r.numProbs
+}
+
+// forEachItem reads all the items using a provided reader and calls
+// onItem for each of them. Invalid items and read errors are reported.
+func (r *reporter) forEachItem(reader mapping.Reader, onItem func(line int, item mapping.Mapping)) error {
+	for {
+		item, err := reader.Read()
+		if err == io.EOF {
+			return nil
+
+		} else if perr, ok := err.(*mapping.ParseError); ok {
+			r.report(perr.Line, "%s", perr.Err)
+			continue
+
+		} else if err != nil {
+			r.report(reader.Line(), "failed to read data: %s", err)
+			return err
+		}
+		onItem(reader.Line(), item)
+	}
+}
+
+// ----------------------------------------------
+
+// side tracks coverage of structure positions in one of the corpora
+type side struct {
+	name string
+	attr attrib.PosAttr
+	size int
+
+	// last is the highest position covered so far
+	last int
+
+	// lastFirst is the first position of the last non-empty range
+	lastFirst int
+}
+
+// ID2Str returns a string ID of a structure at a position
+// (positions outside the structure are not looked up)
+func (s *side) ID2Str(pos int) string {
+	if pos < 0 || pos >= s.size {
+		return "?"
+	}
+	return s.attr.ID2Str(pos)
+}
+
+// Validator checks whether a numeric alignment fully covers
+// structures of both corpora in the form produced by 'import'
+// and 'transalign', i.e.:
+//
+// * ranges on both sides are ordered and do not overlap,
+//
+// * there are no missing positions (0...size-1 on each side),
+//
+// * there are no error records and no [-1, -1] items,
+//
+// * gap flags are used only for one-sided items (as created by fixgaps)
+type Validator struct {
+	reporter
+	side1    side
+	side2    side
+	numItems int
+}
+
+func (v *Validator) describe(item *mapping.Mapping) string {
+	return fmt.Sprintf("[%s -> %s] (%s -> %s)",
+		item.From, item.To, item.From.Describe(&v.side1), item.To.Describe(&v.side2))
+}
+
+func (v *Validator) checkRange(line int, item *mapping.Mapping, rng mapping.PosRange, s *side) {
+	if rng.First == -1 {
+		return
+	}
+	if rng.Last >= s.size {
+		v.report(line, "%s position %d exceeds structure size %d: %s", s.name, rng.Last, s.size, v.describe(item))
+	}
+	if rng.First < s.lastFirst {
+		v.report(line, "%s positions not ordered (%d after %d): %s", s.name, rng.First, s.lastFirst, v.describe(item))
+
+	} else if rng.First <= s.last {
+		v.report(line, "%s positions %d..%d already covered: %s", s.name, rng.First, s.last, v.describe(item))
+
+	} else if rng.First > s.last+1 {
+		v.report(line, "%s positions %d..%d missing (%s..%s) before %s", s.name, s.last+1, rng.First-1,
+			s.ID2Str(s.last+1), s.ID2Str(rng.First-1), v.describe(item))
+	}
+	if rng.Last > s.last {
+		s.last = rng.Last
+	}
+	s.lastFirst = rng.First
+}
+
+// Check checks a single item of the alignment.
+// Items are expected to be passed in the order of the data.
+func (v *Validator) Check(line int, item mapping.Mapping) {
+	v.numItems++
+	if item.IsError() {
+		v.report(line, "the '%s' mark found", mapping.ErrorMark)
+		return
+	}
+	if item.IsEmpty() {
+		v.report(line, "no position on any side")
+		return
+	}
+	if item.IsGap && item.From.First != -1 && item.To.First != -1 {
+		v.report(line, "gap flag used for a two-sided item: %s", v.describe(&item))
+	}
+	v.checkRange(line, &item, item.From, &v.side1)
+	v.checkRange(line, &item, item.To, &v.side2)
+}
+
+// Finish checks whether both sides are covered up to the end
+// of respective structures.
+func (v *Validator) Finish() {
+	for _, s := range []*side{&v.side1, &v.side2} {
+		if s.last < s.size-1 {
+			v.report(0, "%s positions %d..%d missing at the end (%s..%s)", s.name, s.last+1, s.size-1,
+				s.ID2Str(s.last+1), s.ID2Str(s.size-1))
+		}
+	}
+}
+
+// NumItems returns a number of checked items
+func (v *Validator) NumItems() int {
+	return v.numItems
+}
+
+// Run reads a numeric alignment (text or binary) from src and checks all
+// its items. In case the data contain a header, it is compared with
+// the expected one. Found problems are passed to the onProblem function
+// provided to NewValidator. The returned error means that the data
+// could not be read (a respective problem is reported too). Even in such
+// a case, positions missing at the end of the read data are reported.
+func (v *Validator) Run(src io.Reader, expected mapping.Header) error {
+	reader, err := mapping.NewReader(src)
+	if err != nil {
+		v.report(0, "failed to read data: %s", err)
+		return err
+	}
+	if err := reader.Header().Verify(expected); err != nil {
+		v.report(0, "%s", err)
+	}
+	err = v.forEachItem(reader, v.Check)
+	v.Finish()
+	return err
+}
+
+// NewValidator creates a new Validator instance for structures
+// with specified sizes and string IDs (attr1, attr2).
+func NewValidator(attr1, attr2 attrib.PosAttr, size1, size2 int, onProblem func(p Problem)) *Validator {
+	return &Validator{
+		reporter: reporter{onProblem: onProblem},
+		side1:    side{name: "LANG1", attr: attr1, size: size1, last: -1, lastFirst: -1},
+		side2:    side{name: "LANG2", attr: attr2, size: size2, last: -1, lastFirst: -1},
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Charles University, Faculty of Arts,
+//                Institute of the Czech National Corpus
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <http://www.gnu.org/licenses/>.
+
+package validate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/czcorpus/ictools/attrib/attribtest"
+	"github.com/czcorpus/ictools/mapping"
+	"github.com/stretchr/testify/assert"
+)
+
+func validate(data string, size1, size2 int) []Problem {
+	ans := make([]Problem, 0, 10)
+	v := NewValidator(&attribtest.PrefixAttr{Prefix: "cs:s"}, &attribtest.PrefixAttr{Prefix: "en:s"}, size1, size2, func(p Problem) {
+		ans = append(ans, p)
+	})
+	v.Run(strings.NewReader(data), mapping.Header{})
+	return ans
+}
+
+func TestValidData(t *testing.T) {
+	problems := validate("0\t0\n1,2\t1\n-1\t2\tg\n3\t-1\n4\t3,5\n", 5, 6)
+	assert.Equal(t, 0, len(problems))
+}
+
+func TestMissingPositions(t *testing.T) {
+	problems := validate("0\t0\n3\t1\n", 5, 3)
+	assert.Equal(t, 3, len(problems))
+	assert.Equal(t, 2, problems[0].Line)
+	assert.Equal(t, "LANG1 positions 1..2 missing (cs:s:1..cs:s:2) before [3 -> 1] (cs:s:3 -> en:s:1)", problems[0].Message)
+	assert.Equal(t, 0, problems[1].Line)
+	assert.Equal(t, "LANG1 positions 4..4 missing at the end (cs:s:4..cs:s:4)", problems[1].Message)
+	assert.Equal(t, "LANG2 positions 2..2 missing at the end (en:s:2..en:s:2)", problems[2].Message)
+}
+
+func TestOverlapAndOrder(t *testing.T) {
+	problems := validate("0,1\t0\n1\t1\n0\t2\n", 2, 3)
+	assert.Equal(t, 2, len(problems))
+	assert.Equal(t, 2, problems[0].Line)
+	assert.True(t, strings.HasPrefix(problems[0].Message, "LANG1 positions 1..1 already covered"))
+	assert.Equal(t, 3, problems[1].Line)
+	assert.True(t, strings.HasPrefix(problems[1].Message, "LANG1 positions not ordered"))
+}
+
+func TestErrorMarkAndInvalidLines(t *testing.T) {
+	problems := validate("0\t0\nERROR\n1\t-1,1\n-1\t-1\n1\t1\n", 2, 2)
+	assert.Equal(t, 3, len(problems))
+	assert.Equal(t, 2, problems[0].Line)
+	assert.Equal(t, 3, problems[1].Line)
+	assert.Equal(t, 4, problems[2].Line)
+}
+
+func TestGapFlag(t *testing.T) {
+	problems := validate("0\t0\tg\n", 1, 1)
+	assert.Equal(t, 1, len(problems))
+	assert.True(t, strings.HasPrefix(problems[0].Message, "gap flag used for a two-sided item"))
+}
+
+func TestPositionExceedsSize(t *testing.T) {
+	problems := validate("0\t0\n1\t1,2\n", 2, 2)
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, "LANG2 position 2 exceeds structure size 2: [1 -> 1,2] (cs:s:1 -> en:s:1..?)", problems[0].Message)
+}
+
+func TestHeaderMismatch(t *testing.T) {
+	problems := make([]Problem, 0, 10)
+	v := NewValidator(&attribtest.PrefixAttr{Prefix: "cs:s"}, &attribtest.PrefixAttr{Prefix: "en:s"}, 1, 1, func(p Problem) {
+		problems = append(problems, p)
+	})
+	v.Run(strings.NewReader("#@ corpus1=ic_pl\n0\t0\n#@ checksum=crc32:671bcf4d\n"), mapping.Header{Corpus1: "ic_cs"})
+	assert.Equal(t, 1, len(problems))
+	assert.Equal(t, 0, problems[0].Line)
+}
+
+func TestPositionExceedsSizeWithChecksum(t *testing.T) {
+	var data bytes.Buffer
+	w := mapping.NewTextWriter(&data, mapping.Header{Corpus1: "ic_cs", Size1: 2, Size2: 2})
+	for _, item := range []mapping.Mapping{
+		{From: mapping.PosRange{First: 0, Last: 0}, To: mapping.PosRange{First: 0, Last: 0}},
+		{From: mapping.PosRange{First: 1, Last: 1}, To: mapping.PosRange{First: 1, Last: 5}},
+	} {
+		assert.Nil(t, w.Write(item))
+	}
+	assert.Nil(t, w.Close())
+	problems := validate(data.String(), 2, 2)
+	assert.Equal(t, 3, len(problems))
+	assert.True(t, strings.HasPrefix(problems[0].Message, "Position 5 exceeds structure size 2"))
+	for _, p := range problems {
+		assert.False(t, strings.HasPrefix(p.Message, "failed to read data"))
+	}
+}
+
+func TestMissingPositionsAfterReadError(t *testing.T) {
+	problems := validate("#@ corpus1=ic_cs\n0\t0\n#@ checksum=crc32:00000000\n", 3, 1)
+	assert.Equal(t, 2, len(problems))
+	assert.True(t, strings.HasPrefix(problems[0].Message, "failed to read data"))
+	assert.Equal(t, "LANG1 positions 1..2 missing at the end (cs:s:1..cs:s:2)", problems[1].Message)
+}